@@ -0,0 +1,185 @@
+package azureSdkForGo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCatalogCacheTTL = 10 * time.Minute
+)
+
+// CatalogCacheStats counts the cached GET requests since the process started
+// or the stats were reset. Hits were served from the cache, Misses were sent to
+// the service and Waits shared the response of a request already in flight.
+// Entries is the number of responses that have not expired yet.
+type CatalogCacheStats struct {
+	Hits    int64
+	Misses  int64
+	Waits   int64
+	Entries int
+}
+
+type catalogCacheEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+type catalogCacheCall struct {
+	done     chan struct{}
+	response []byte
+	err      error
+}
+
+type catalogCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]catalogCacheEntry
+	calls   map[string]*catalogCacheCall
+	stats   CatalogCacheStats
+	send    func(url string) ([]byte, error)
+}
+
+//Region public methods starts
+
+// SendAzureCachedGetRequest is SendAzureGetRequest for catalogs that rarely
+// change, such as locations, role sizes and images. Responses are kept for the
+// catalog cache TTL and concurrent requests for the same url share one call.
+func SendAzureCachedGetRequest(url string) ([]byte, error) {
+	if len(url) == 0 {
+		return nil, fmt.Errorf(ParamNotSpecifiedError, "url")
+	}
+
+	return settings.catalogCache.get(settings.SubscriptionID+"/"+url, url)
+}
+
+// SendAzureGetRequestWithCache sends a cached GET request when useCache is set
+// and a plain one otherwise.
+func SendAzureGetRequestWithCache(url string, useCache bool) ([]byte, error) {
+	if useCache {
+		return SendAzureCachedGetRequest(url)
+	}
+
+	return SendAzureGetRequest(url)
+}
+
+// SetCatalogCacheTTL changes how long cached responses are used. A ttl of
+// zero or less disables the cache.
+func SetCatalogCacheTTL(ttl time.Duration) {
+	cache := settings.catalogCache
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.ttl = ttl
+	if ttl <= 0 {
+		cache.entries = make(map[string]catalogCacheEntry)
+	}
+}
+
+// InvalidateCatalogCache drops the cached responses for urls, or every cached
+// response when no url is given.
+func InvalidateCatalogCache(urls ...string) {
+	cache := settings.catalogCache
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if len(urls) == 0 {
+		cache.entries = make(map[string]catalogCacheEntry)
+		return
+	}
+
+	for _, url := range urls {
+		delete(cache.entries, settings.SubscriptionID+"/"+url)
+	}
+}
+
+func GetCatalogCacheStats() CatalogCacheStats {
+	return settings.catalogCache.getStats()
+}
+
+func ResetCatalogCacheStats() {
+	cache := settings.catalogCache
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.stats = CatalogCacheStats{}
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func newCatalogCache(ttl time.Duration) *catalogCache {
+	return &catalogCache{
+		ttl:     ttl,
+		entries: make(map[string]catalogCacheEntry),
+		calls:   make(map[string]*catalogCacheCall),
+	}
+}
+
+func (c *catalogCache) get(key, url string) ([]byte, error) {
+	c.mutex.Lock()
+	if entry, ok := c.entries[key]; ok {
+		if time.Now().Before(entry.expires) {
+			c.stats.Hits++
+			c.mutex.Unlock()
+			return entry.response, nil
+		}
+
+		delete(c.entries, key)
+	}
+
+	if call, ok := c.calls[key]; ok {
+		c.stats.Waits++
+		c.mutex.Unlock()
+		<-call.done
+		return call.response, call.err
+	}
+
+	c.stats.Misses++
+	call := &catalogCacheCall{done: make(chan struct{})}
+	c.calls[key] = call
+	send := c.send
+	c.mutex.Unlock()
+
+	if send == nil {
+		send = SendAzureGetRequest
+	}
+	call.response, call.err = send(url)
+
+	c.mutex.Lock()
+	delete(c.calls, key)
+	if call.err == nil && c.ttl > 0 {
+		c.entries[key] = catalogCacheEntry{response: call.response, expires: time.Now().Add(c.ttl)}
+	}
+	c.mutex.Unlock()
+
+	close(call.done)
+	return call.response, call.err
+}
+
+func (c *catalogCache) getStats() CatalogCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+func (c *catalogCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[string]catalogCacheEntry)
+}
+
+//Region private methods ends
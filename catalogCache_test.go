@@ -0,0 +1,168 @@
+package azureSdkForGo
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestCatalogCache(ttl time.Duration, send func(url string) ([]byte, error)) *catalogCache {
+	cache := newCatalogCache(ttl)
+	cache.send = send
+	return cache
+}
+
+func Test_catalogCacheTTL(t *testing.T) {
+	sent := 0
+	cache := newTestCatalogCache(time.Hour, func(url string) ([]byte, error) {
+		sent++
+		return []byte(url), nil
+	})
+
+	testCases := []struct {
+		expire         bool
+		expectedSent   int
+		expectedHits   int64
+		expectedMisses int64
+	}{
+		{false, 1, 0, 1},
+		{false, 1, 1, 1},
+		{true, 2, 1, 2},
+		{false, 2, 2, 2},
+	}
+
+	for i, testCase := range testCases {
+		if testCase.expire {
+			entry := cache.entries["key"]
+			entry.expires = time.Now().Add(-time.Second)
+			cache.entries["key"] = entry
+		}
+
+		response, err := cache.get("key", "locations")
+		if err != nil {
+			t.Fatalf("Case %d: unexpected error: %s", i, err)
+		}
+		if string(response) != "locations" {
+			t.Errorf("Case %d: expected: locations, got: %s", i, response)
+		}
+		if sent != testCase.expectedSent {
+			t.Errorf("Case %d: expected: %d requests, got: %d", i, testCase.expectedSent, sent)
+		}
+
+		stats := cache.getStats()
+		if stats.Hits != testCase.expectedHits || stats.Misses != testCase.expectedMisses || stats.Entries != 1 {
+			t.Errorf("Case %d: expected: %d hits, %d misses and 1 entry, got: %+v", i, testCase.expectedHits, testCase.expectedMisses, stats)
+		}
+	}
+}
+
+func Test_catalogCacheEvictsExpiredEntries(t *testing.T) {
+	cache := newTestCatalogCache(time.Hour, func(url string) ([]byte, error) {
+		return []byte(url), nil
+	})
+
+	cache.get("first", "first")
+	cache.get("second", "second")
+
+	entry := cache.entries["first"]
+	entry.expires = time.Now().Add(-time.Second)
+	cache.entries["first"] = entry
+
+	stats := cache.getStats()
+	if stats.Entries != 1 {
+		t.Errorf("Expected: 1 entry, got: %d", stats.Entries)
+	}
+	if _, ok := cache.entries["first"]; ok {
+		t.Errorf("Expected: expired entry to be evicted")
+	}
+}
+
+func Test_catalogCacheDisabled(t *testing.T) {
+	sent := 0
+	cache := newTestCatalogCache(0, func(url string) ([]byte, error) {
+		sent++
+		return []byte(url), nil
+	})
+
+	cache.get("key", "sizes")
+	cache.get("key", "sizes")
+
+	if sent != 2 {
+		t.Errorf("Expected: 2 requests, got: %d", sent)
+	}
+	if stats := cache.getStats(); stats.Entries != 0 || stats.Misses != 2 {
+		t.Errorf("Expected: 2 misses and no entry, got: %+v", stats)
+	}
+}
+
+func Test_catalogCacheDoesNotKeepErrors(t *testing.T) {
+	sent := 0
+	cache := newTestCatalogCache(time.Hour, func(url string) ([]byte, error) {
+		sent++
+		return nil, errors.New("unavailable")
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.get("key", "images")
+		if err == nil || err.Error() != "unavailable" {
+			t.Errorf("Expected: unavailable, got: %v", err)
+		}
+	}
+
+	if sent != 2 {
+		t.Errorf("Expected: 2 requests, got: %d", sent)
+	}
+}
+
+func Test_catalogCacheSingleFlight(t *testing.T) {
+	const callers = 5
+
+	sent := 0
+	release := make(chan struct{})
+	cache := newTestCatalogCache(time.Hour, func(url string) ([]byte, error) {
+		sent++
+		<-release
+		return []byte(url), nil
+	})
+
+	var group sync.WaitGroup
+	responses := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		group.Add(1)
+		go func(i int) {
+			defer group.Done()
+			response, _ := cache.get("key", "extensions")
+			responses[i] = string(response)
+		}(i)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := cache.getStats()
+		if stats.Misses+stats.Waits == callers {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected: %d callers waiting, got: %+v", callers, stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	group.Wait()
+
+	if sent != 1 {
+		t.Errorf("Expected: 1 request, got: %d", sent)
+	}
+	for _, response := range responses {
+		if response != "extensions" {
+			t.Errorf("Expected: extensions, got: %s", response)
+		}
+	}
+
+	stats := cache.getStats()
+	if stats.Hits != 0 || stats.Misses != 1 || stats.Waits != callers-1 {
+		t.Errorf("Expected: 0 hits, 1 miss and %d waits, got: %+v", callers-1, stats)
+	}
+}
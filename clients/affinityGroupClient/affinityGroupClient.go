@@ -0,0 +1,147 @@
+package affinityGroupClient
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/locationClient"
+)
+
+const (
+	azureXmlns                = "http://schemas.microsoft.com/windowsazure"
+	azureAffinityGroupListURL = "affinitygroups"
+	azureAffinityGroupURL     = "affinitygroups/%s"
+)
+
+func GetAffinityGroupList() (*AffinityGroupList, error) {
+	affinityGroupList := new(AffinityGroupList)
+
+	response, err := azure.SendAzureGetRequest(azureAffinityGroupListURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, affinityGroupList)
+	if err != nil {
+		return nil, err
+	}
+
+	return affinityGroupList, nil
+}
+
+func GetAffinityGroup(name string) (*AffinityGroup, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	affinityGroup := new(AffinityGroup)
+
+	requestURL := fmt.Sprintf(azureAffinityGroupURL, name)
+	response, err := azure.SendAzureGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, affinityGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	return affinityGroup, nil
+}
+
+func CreateAffinityGroup(name, label, description, location string) (*AffinityGroup, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+	if len(location) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "location")
+	}
+
+	err := locationClient.ResolveLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(label) == 0 {
+		label = name
+	}
+
+	affinityGroupDeployment := createAffinityGroupDeploymentConf(name, label, description, location)
+	deploymentBytes, err := xml.Marshal(affinityGroupDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	requestId, err := azure.SendAzurePostRequest(azureAffinityGroupListURL, deploymentBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetAffinityGroup(name)
+}
+
+func UpdateAffinityGroup(name, label, description string) error {
+	if len(name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+	if len(label) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "label")
+	}
+
+	affinityGroupUpdate := createAffinityGroupUpdateConf(label, description)
+	updateBytes, err := xml.Marshal(affinityGroupUpdate)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureAffinityGroupURL, name)
+	requestId, err := azure.SendAzurePutRequest(requestURL, updateBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func DeleteAffinityGroup(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	requestURL := fmt.Sprintf(azureAffinityGroupURL, name)
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func createAffinityGroupDeploymentConf(name, label, description, location string) AffinityGroupDeployment {
+	affinityGroupDeployment := AffinityGroupDeployment{}
+
+	affinityGroupDeployment.Name = name
+	affinityGroupDeployment.Label = base64.StdEncoding.EncodeToString([]byte(label))
+	affinityGroupDeployment.Description = description
+	affinityGroupDeployment.Location = location
+	affinityGroupDeployment.Xmlns = azureXmlns
+
+	return affinityGroupDeployment
+}
+
+func createAffinityGroupUpdateConf(label, description string) AffinityGroupUpdate {
+	affinityGroupUpdate := AffinityGroupUpdate{}
+
+	affinityGroupUpdate.Label = base64.StdEncoding.EncodeToString([]byte(label))
+	affinityGroupUpdate.Description = description
+	affinityGroupUpdate.Xmlns = azureXmlns
+
+	return affinityGroupUpdate
+}
@@ -0,0 +1,42 @@
+package affinityGroupClient
+
+import (
+	"encoding/xml"
+)
+
+type AffinityGroupList struct {
+	XMLName        xml.Name        `xml:"AffinityGroups"`
+	Xmlns          string          `xml:"xmlns,attr"`
+	AffinityGroups []AffinityGroup `xml:"AffinityGroup"`
+}
+
+type AffinityGroup struct {
+	Name            string
+	Label           string
+	Description     string
+	Location        string
+	HostedServices  []AffinityGroupService `xml:"HostedServices>HostedService"`
+	StorageServices []AffinityGroupService `xml:"StorageServices>StorageService"`
+	Capabilities    []string               `xml:"Capabilities>Capability"`
+}
+
+type AffinityGroupService struct {
+	Url         string
+	ServiceName string
+}
+
+type AffinityGroupDeployment struct {
+	XMLName     xml.Name `xml:"CreateAffinityGroup"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	Name        string
+	Label       string
+	Description string `xml:",omitempty"`
+	Location    string
+}
+
+type AffinityGroupUpdate struct {
+	XMLName     xml.Name `xml:"UpdateAffinityGroup"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	Label       string
+	Description string `xml:",omitempty"`
+}
@@ -0,0 +1,560 @@
+package autoscaleClient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/metricsClient"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/vmClient"
+)
+
+const (
+	AutoscaleActionScaleOut = "ScaleOut"
+	AutoscaleActionScaleIn  = "ScaleIn"
+
+	OperatorGreaterThan        = ">"
+	OperatorGreaterThanOrEqual = ">="
+	OperatorLessThan           = "<"
+	OperatorLessThanOrEqual    = "<="
+
+	defaultEvaluationInterval = time.Minute
+	defaultReadyTimeout       = 15 * time.Minute
+
+	powerStateStarted  = "Started"
+	powerStateStarting = "Starting"
+	powerStateStopped  = "Stopped"
+
+	invalidAutoscaleActionError   = "Invalid action %s for rule %s. Valid values are 'ScaleOut' and 'ScaleIn'"
+	invalidAutoscaleOperatorError = "Invalid operator %s for rule %s. Valid values are '>', '>=', '<' and '<='"
+	invalidDurationError          = "Invalid %s %s for rule %s: %s"
+	duplicateRuleError            = "Rule %s is defined more than once"
+	invalidInstanceLimitsError    = "Rule %s has minInstances %d greater than maxInstances %d"
+	unknownRuleError              = "Unknown autoscale rule: %s"
+
+	conditionNotMetReason       = "condition not met"
+	noRunningRolesReason        = "no running roles in availability set"
+	noMetricValuesReason        = "no metric values in window"
+	cooldownReason              = "in cooldown until %s"
+	noStoppedRolesReason        = "no stopped roles left to start"
+	maxInstancesReason          = "already at %d running roles, maximum is %d"
+	minInstancesReason          = "already at %d running roles, minimum is %d"
+	availabilitySetScaledReason = "availability set already scaled in this evaluation"
+)
+
+type Autoscaler struct {
+	cloudserviceName string
+	deploymentName   string
+	rules            []autoscaleRule
+	metrics          MetricsSource
+
+	// DryRun makes the autoscaler only report its decisions without
+	// starting or stopping any role. Decisions and errors are written to
+	// Output when it is set.
+	DryRun       bool
+	Output       io.Writer
+	ReadyTimeout time.Duration
+
+	mutex sync.Mutex
+	// lastAction holds the time of the last action per availability set, so
+	// the cooldown also holds off the other rules scaling the same set
+	lastAction map[string]time.Time
+	roles      roleOperations
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// roleOperations starts, stops and waits for roles. It is replaced in tests.
+type roleOperations struct {
+	start        func(cloudserviceName, deploymentName, roleName string) error
+	shutdown     func(cloudserviceName, deploymentName, roleName, postShutdownAction string) error
+	waitForReady func(cloudserviceName, deploymentName, roleName string, timeout time.Duration) error
+}
+
+type autoscaleRule struct {
+	AutoscaleRule
+	window   time.Duration
+	cooldown time.Duration
+}
+
+//Region public methods starts
+
+func LoadAutoscaleRules(path string) ([]AutoscaleRule, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "path")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseAutoscaleRules(data)
+}
+
+func ParseAutoscaleRules(data []byte) ([]AutoscaleRule, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "data")
+	}
+
+	rules := []AutoscaleRule{}
+	err := json.Unmarshal(data, &rules)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		_, err = normalizeAutoscaleRule(&rules[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+// NewAutoscaler creates an autoscaler for the deployment. When metrics is nil
+// the values are read from the Azure monitoring service.
+func NewAutoscaler(cloudserviceName, deploymentName string, rules []AutoscaleRule, metrics MetricsSource) (*Autoscaler, error) {
+	if len(cloudserviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "rules")
+	}
+
+	if metrics == nil {
+		metrics = NewAzureMetricsSource(metricsClient.TimeGrain5Minutes)
+	}
+
+	autoscaler := &Autoscaler{
+		cloudserviceName: cloudserviceName,
+		deploymentName:   deploymentName,
+		metrics:          metrics,
+		ReadyTimeout:     defaultReadyTimeout,
+		lastAction:       make(map[string]time.Time),
+		roles:            newVMRoleOperations(),
+		stop:             make(chan struct{}),
+	}
+
+	ruleNames := map[string]bool{}
+	for _, rule := range rules {
+		compiledRule, err := normalizeAutoscaleRule(&rule)
+		if err != nil {
+			return nil, err
+		}
+		if ruleNames[rule.Name] {
+			return nil, fmt.Errorf(duplicateRuleError, rule.Name)
+		}
+
+		ruleNames[rule.Name] = true
+		autoscaler.rules = append(autoscaler.rules, compiledRule)
+	}
+
+	return autoscaler, nil
+}
+
+// Evaluate returns the decision of every rule for the given deployment state
+// without acting on it. Rules are evaluated in order, and only the first rule
+// taking action on an availability set applies in a single evaluation.
+func (autoscaler *Autoscaler) Evaluate(deployment *vmClient.VMDeployment, now time.Time) ([]AutoscaleDecision, error) {
+	if deployment == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "deployment")
+	}
+
+	autoscaler.mutex.Lock()
+	lastAction := make(map[string]time.Time, len(autoscaler.lastAction))
+	for name, actionTime := range autoscaler.lastAction {
+		lastAction[name] = actionTime
+	}
+	autoscaler.mutex.Unlock()
+
+	decisions := []AutoscaleDecision{}
+	scaledSets := map[string]bool{}
+	for _, rule := range autoscaler.rules {
+		decision, err := autoscaler.evaluateRule(rule, deployment, now, lastAction[rule.AvailabilitySetName])
+		if err != nil {
+			return nil, err
+		}
+
+		if !decision.Skipped && scaledSets[rule.AvailabilitySetName] {
+			decision.Skipped = true
+			decision.RoleNames = nil
+			decision.Reason = availabilitySetScaledReason
+		}
+		if !decision.Skipped {
+			scaledSets[rule.AvailabilitySetName] = true
+		}
+
+		decisions = append(decisions, decision)
+	}
+
+	return decisions, nil
+}
+
+// RunOnce reads the deployment, evaluates the rules and applies the resulting
+// decisions, or only reports them in dry-run mode.
+func (autoscaler *Autoscaler) RunOnce(now time.Time) ([]AutoscaleDecision, error) {
+	deployment, err := vmClient.GetVMDeployment(autoscaler.cloudserviceName, autoscaler.deploymentName)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, err := autoscaler.Evaluate(deployment, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return decisions, autoscaler.applyDecisions(decisions, now)
+}
+
+// Run evaluates the rules every interval until Stop is called. Errors of a
+// single evaluation are written to Output and do not stop the autoscaler.
+func (autoscaler *Autoscaler) Run(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultEvaluationInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := autoscaler.RunOnce(time.Now())
+		if err != nil {
+			autoscaler.writeOutput("error: " + err.Error())
+		}
+
+		select {
+		case <-ticker.C:
+		case <-autoscaler.stop:
+			return
+		}
+	}
+}
+
+func (autoscaler *Autoscaler) Stop() {
+	autoscaler.stopOnce.Do(func() {
+		close(autoscaler.stop)
+	})
+}
+
+func (decision AutoscaleDecision) String() string {
+	prefix := fmt.Sprintf("%s rule %s: %s %.2f %s %.2f", decision.Time.UTC().Format(time.RFC3339), decision.Rule, decision.Metric, decision.Value, decision.Operator, decision.Threshold)
+	if decision.Skipped {
+		return fmt.Sprintf("%s, %s skipped: %s", prefix, decision.Action, decision.Reason)
+	}
+
+	verb := "start"
+	if decision.Action == AutoscaleActionScaleIn {
+		verb = "shut down"
+	}
+
+	return fmt.Sprintf("%s, %s %s", prefix, verb, strings.Join(decision.RoleNames, ", "))
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func normalizeAutoscaleRule(rule *AutoscaleRule) (autoscaleRule, error) {
+	compiledRule := autoscaleRule{}
+
+	if len(rule.Name) == 0 {
+		return compiledRule, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+	if len(rule.AvailabilitySetName) == 0 {
+		return compiledRule, fmt.Errorf(azure.ParamNotSpecifiedError, "availabilitySet")
+	}
+	if len(rule.Window) == 0 {
+		return compiledRule, fmt.Errorf(azure.ParamNotSpecifiedError, "window")
+	}
+
+	if len(rule.Metric) == 0 {
+		rule.Metric = metricsClient.MetricPercentageCPU
+	}
+	if len(rule.Aggregation) == 0 {
+		rule.Aggregation = metricsClient.AggregationAverage
+	}
+	if rule.InstanceCount <= 0 {
+		rule.InstanceCount = 1
+	}
+	if rule.MinInstances <= 0 {
+		rule.MinInstances = 1
+	}
+	if len(rule.PostShutdownAction) == 0 {
+		rule.PostShutdownAction = vmClient.PostShutdownActionStoppedDeallocated
+	}
+
+	if rule.Action != AutoscaleActionScaleOut && rule.Action != AutoscaleActionScaleIn {
+		return compiledRule, fmt.Errorf(invalidAutoscaleActionError, rule.Action, rule.Name)
+	}
+
+	switch rule.Operator {
+	case OperatorGreaterThan, OperatorGreaterThanOrEqual, OperatorLessThan, OperatorLessThanOrEqual:
+	default:
+		return compiledRule, fmt.Errorf(invalidAutoscaleOperatorError, rule.Operator, rule.Name)
+	}
+
+	if rule.MaxInstances > 0 && rule.MinInstances > rule.MaxInstances {
+		return compiledRule, fmt.Errorf(invalidInstanceLimitsError, rule.Name, rule.MinInstances, rule.MaxInstances)
+	}
+
+	window, err := time.ParseDuration(rule.Window)
+	if err != nil || window <= 0 {
+		return compiledRule, fmt.Errorf(invalidDurationError, "window", rule.Window, rule.Name, err)
+	}
+
+	var cooldown time.Duration
+	if len(rule.Cooldown) != 0 {
+		cooldown, err = time.ParseDuration(rule.Cooldown)
+		if err != nil || cooldown < 0 {
+			return compiledRule, fmt.Errorf(invalidDurationError, "cooldown", rule.Cooldown, rule.Name, err)
+		}
+	}
+
+	compiledRule.AutoscaleRule = *rule
+	compiledRule.window = window
+	compiledRule.cooldown = cooldown
+	return compiledRule, nil
+}
+
+func (autoscaler *Autoscaler) evaluateRule(rule autoscaleRule, deployment *vmClient.VMDeployment, now time.Time, lastAction time.Time) (AutoscaleDecision, error) {
+	decision := AutoscaleDecision{
+		Time:                now,
+		Rule:                rule.Name,
+		AvailabilitySetName: rule.AvailabilitySetName,
+		Action:              rule.Action,
+		Metric:              rule.Metric,
+		Operator:            rule.Operator,
+		Threshold:           rule.Threshold,
+		Skipped:             true,
+	}
+
+	runningRoles, startingRoles, stoppedRoles := getAvailabilitySetRoles(deployment, rule.AvailabilitySetName)
+	if len(runningRoles) == 0 {
+		decision.Reason = noRunningRolesReason
+		return decision, nil
+	}
+
+	valueSet := metricsClient.MetricValueSet{Name: rule.Metric}
+	for _, roleName := range runningRoles {
+		points, err := autoscaler.metrics.GetRoleMetric(autoscaler.cloudserviceName, autoscaler.deploymentName, roleName, rule.Metric, now.Add(-rule.window), now)
+		if err != nil {
+			return decision, err
+		}
+
+		valueSet.MetricValues = append(valueSet.MetricValues, points...)
+	}
+
+	if len(valueSet.MetricValues) == 0 {
+		decision.Reason = noMetricValuesReason
+		return decision, nil
+	}
+
+	value, err := valueSet.Aggregate(rule.Aggregation)
+	if err != nil {
+		return decision, err
+	}
+	decision.Value = value
+
+	if !compareMetricValue(value, rule.Operator, rule.Threshold) {
+		decision.Reason = conditionNotMetReason
+		return decision, nil
+	}
+
+	if !lastAction.IsZero() && now.Before(lastAction.Add(rule.cooldown)) {
+		decision.Reason = fmt.Sprintf(cooldownReason, lastAction.Add(rule.cooldown).UTC().Format(time.RFC3339))
+		return decision, nil
+	}
+
+	activeCount := len(runningRoles) + len(startingRoles)
+	switch rule.Action {
+	case AutoscaleActionScaleOut:
+		count := rule.InstanceCount
+		if rule.MaxInstances > 0 && activeCount+count > rule.MaxInstances {
+			count = rule.MaxInstances - activeCount
+		}
+		if count <= 0 {
+			decision.Reason = fmt.Sprintf(maxInstancesReason, activeCount, rule.MaxInstances)
+			return decision, nil
+		}
+		if len(stoppedRoles) == 0 {
+			decision.Reason = noStoppedRolesReason
+			return decision, nil
+		}
+		if count > len(stoppedRoles) {
+			count = len(stoppedRoles)
+		}
+
+		decision.RoleNames = stoppedRoles[:count]
+	case AutoscaleActionScaleIn:
+		count := rule.InstanceCount
+		if activeCount-count < rule.MinInstances {
+			count = activeCount - rule.MinInstances
+		}
+		if count > len(runningRoles) {
+			count = len(runningRoles)
+		}
+		if count <= 0 {
+			decision.Reason = fmt.Sprintf(minInstancesReason, activeCount, rule.MinInstances)
+			return decision, nil
+		}
+
+		decision.RoleNames = runningRoles[len(runningRoles)-count:]
+	}
+
+	decision.Skipped = false
+	return decision, nil
+}
+
+// applyDecisions acts on every decision that was not skipped. The cooldown of
+// an availability set starts before its roles are touched, so a decision that
+// fails halfway is not retried on the next evaluation. A failed decision does
+// not keep the others from being applied, their errors are returned together.
+func (autoscaler *Autoscaler) applyDecisions(decisions []AutoscaleDecision, now time.Time) error {
+	failures := []string{}
+	for _, decision := range decisions {
+		autoscaler.writeOutput(decision.String())
+		if decision.Skipped {
+			continue
+		}
+
+		autoscaler.mutex.Lock()
+		autoscaler.lastAction[decision.AvailabilitySetName] = now
+		autoscaler.mutex.Unlock()
+
+		if autoscaler.DryRun {
+			continue
+		}
+
+		err := autoscaler.applyDecision(decision)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", decision.Rule, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+func (autoscaler *Autoscaler) applyDecision(decision AutoscaleDecision) error {
+	rule := autoscaler.findRule(decision.Rule)
+	if rule == nil {
+		return errors.New(fmt.Sprintf(unknownRuleError, decision.Rule))
+	}
+
+	for _, roleName := range decision.RoleNames {
+		switch decision.Action {
+		case AutoscaleActionScaleOut:
+			err := autoscaler.roles.start(autoscaler.cloudserviceName, autoscaler.deploymentName, roleName)
+			if err != nil {
+				return err
+			}
+
+			err = autoscaler.roles.waitForReady(autoscaler.cloudserviceName, autoscaler.deploymentName, roleName, autoscaler.ReadyTimeout)
+			if err != nil {
+				return err
+			}
+		case AutoscaleActionScaleIn:
+			err := autoscaler.roles.shutdown(autoscaler.cloudserviceName, autoscaler.deploymentName, roleName, rule.PostShutdownAction)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newVMRoleOperations() roleOperations {
+	return roleOperations{
+		start:    vmClient.StartRole,
+		shutdown: vmClient.ShutdownRoleWithPostShutdownAction,
+		waitForReady: func(cloudserviceName, deploymentName, roleName string, timeout time.Duration) error {
+			_, err := vmClient.WaitForRoleReady(cloudserviceName, deploymentName, roleName, timeout)
+			return err
+		},
+	}
+}
+
+func (autoscaler *Autoscaler) findRule(name string) *autoscaleRule {
+	for i := range autoscaler.rules {
+		if autoscaler.rules[i].Name == name {
+			return &autoscaler.rules[i]
+		}
+	}
+
+	return nil
+}
+
+func (autoscaler *Autoscaler) writeOutput(line string) {
+	if autoscaler.Output == nil {
+		return
+	}
+
+	fmt.Fprintln(autoscaler.Output, line)
+}
+
+// getAvailabilitySetRoles returns the names of the roles in the availability
+// set grouped by power state, each group sorted by name.
+func getAvailabilitySetRoles(deployment *vmClient.VMDeployment, availabilitySetName string) ([]string, []string, []string) {
+	setRoles := map[string]bool{}
+	for _, role := range deployment.RoleList.Role {
+		if role.AvailabilitySetName == availabilitySetName {
+			setRoles[role.RoleName] = true
+		}
+	}
+
+	runningRoles := []string{}
+	startingRoles := []string{}
+	stoppedRoles := []string{}
+	for _, instance := range deployment.RoleInstanceList.RoleInstance {
+		if !setRoles[instance.RoleName] {
+			continue
+		}
+
+		switch instance.PowerState {
+		case powerStateStarted:
+			runningRoles = append(runningRoles, instance.RoleName)
+		case powerStateStarting:
+			startingRoles = append(startingRoles, instance.RoleName)
+		case powerStateStopped:
+			stoppedRoles = append(stoppedRoles, instance.RoleName)
+		}
+	}
+
+	sort.Strings(runningRoles)
+	sort.Strings(startingRoles)
+	sort.Strings(stoppedRoles)
+	return runningRoles, startingRoles, stoppedRoles
+}
+
+func compareMetricValue(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case OperatorGreaterThan:
+		return value > threshold
+	case OperatorGreaterThanOrEqual:
+		return value >= threshold
+	case OperatorLessThan:
+		return value < threshold
+	case OperatorLessThanOrEqual:
+		return value <= threshold
+	}
+
+	return false
+}
+
+//Region private methods ends
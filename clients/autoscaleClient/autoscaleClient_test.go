@@ -0,0 +1,344 @@
+package autoscaleClient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/metricsClient"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/vmClient"
+)
+
+type testRole struct {
+	name            string
+	availabilitySet string
+	powerState      string
+	cpu             float64
+}
+
+var testNow = time.Date(2015, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestDeployment(roles []testRole) (*vmClient.VMDeployment, *StaticMetricsSource) {
+	deployment := &vmClient.VMDeployment{}
+	metrics := NewStaticMetricsSource()
+	for _, role := range roles {
+		deployment.RoleList.Role = append(deployment.RoleList.Role, &vmClient.Role{RoleName: role.name, AvailabilitySetName: role.availabilitySet})
+		deployment.RoleInstanceList.RoleInstance = append(deployment.RoleInstanceList.RoleInstance, &vmClient.RoleInstance{RoleName: role.name, PowerState: role.powerState})
+		metrics.AddPoints(role.name, metricsClient.MetricPercentageCPU, metricsClient.MetricPoint{Timestamp: testNow.Add(-time.Minute), Average: role.cpu, Count: 1})
+	}
+
+	return deployment, metrics
+}
+
+func newTestRule(name, availabilitySet, action, operator string, threshold float64) AutoscaleRule {
+	return AutoscaleRule{
+		Name:                name,
+		AvailabilitySetName: availabilitySet,
+		Operator:            operator,
+		Threshold:           threshold,
+		Window:              "10m",
+		Action:              action,
+		Cooldown:            "15m",
+	}
+}
+
+func evaluateTestRules(t *testing.T, roles []testRole, rules ...AutoscaleRule) []AutoscaleDecision {
+	deployment, metrics := newTestDeployment(roles)
+	autoscaler, err := NewAutoscaler("service", "deployment", rules, metrics)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	decisions, err := autoscaler.Evaluate(deployment, testNow)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	return decisions
+}
+
+func Test_Evaluate(t *testing.T) {
+	webRoles := []testRole{
+		{"web1", "web", powerStateStarted, 80},
+		{"web2", "web", powerStateStarted, 60},
+		{"web3", "web", powerStateStopped, 0},
+		{"web4", "web", powerStateStopped, 0},
+	}
+
+	scaleOut := newTestRule("out", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 65)
+	scaleOutBy3 := scaleOut
+	scaleOutBy3.InstanceCount = 3
+	scaleOutMax2 := scaleOut
+	scaleOutMax2.MaxInstances = 2
+	scaleOutMax3 := scaleOutBy3
+	scaleOutMax3.MaxInstances = 3
+	scaleIn := newTestRule("in", "web", AutoscaleActionScaleIn, OperatorLessThanOrEqual, 70)
+	scaleInMin2 := scaleIn
+	scaleInMin2.MinInstances = 2
+	scaleInBy2 := scaleIn
+	scaleInBy2.InstanceCount = 2
+
+	testCases := []struct {
+		name           string
+		roles          []testRole
+		rule           AutoscaleRule
+		expectedRoles  []string
+		expectedReason string
+	}{
+		{"average above threshold", webRoles, scaleOut, []string{"web3"}, ""},
+		{"average at threshold", webRoles, newTestRule("out", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 70), nil, conditionNotMetReason},
+		{"average at inclusive threshold", webRoles, newTestRule("out", "web", AutoscaleActionScaleOut, OperatorGreaterThanOrEqual, 70), []string{"web3"}, ""},
+		{"limited by stopped roles", webRoles, scaleOutBy3, []string{"web3", "web4"}, ""},
+		{"at max instances", webRoles, scaleOutMax2, nil, fmt.Sprintf(maxInstancesReason, 2, 2)},
+		{"limited by max instances", webRoles, scaleOutMax3, []string{"web3"}, ""},
+		{"scale in", webRoles, scaleIn, []string{"web2"}, ""},
+		{"at min instances", webRoles, scaleInMin2, nil, fmt.Sprintf(minInstancesReason, 2, 2)},
+		{"limited by min instances", webRoles, scaleInBy2, []string{"web2"}, ""},
+		{"no stopped roles", webRoles[:2], scaleOut, nil, noStoppedRolesReason},
+		{"no running roles", webRoles[2:], scaleOut, nil, noRunningRolesReason},
+		{"other availability set", webRoles, newTestRule("out", "api", AutoscaleActionScaleOut, OperatorGreaterThan, 65), nil, noRunningRolesReason},
+	}
+
+	for _, testCase := range testCases {
+		decisions := evaluateTestRules(t, testCase.roles, testCase.rule)
+		if len(decisions) != 1 {
+			t.Fatalf("%s: expected 1 decision, got %d", testCase.name, len(decisions))
+		}
+
+		decision := decisions[0]
+		if decision.Skipped != (len(testCase.expectedReason) > 0) {
+			t.Errorf("%s: expected skipped %v, got %v (%s)", testCase.name, len(testCase.expectedReason) > 0, decision.Skipped, decision.Reason)
+		}
+		if decision.Reason != testCase.expectedReason {
+			t.Errorf("%s: expected reason %q, got %q", testCase.name, testCase.expectedReason, decision.Reason)
+		}
+		if len(testCase.expectedRoles) > 0 && !reflect.DeepEqual(decision.RoleNames, testCase.expectedRoles) {
+			t.Errorf("%s: expected roles %v, got %v", testCase.name, testCase.expectedRoles, decision.RoleNames)
+		}
+	}
+}
+
+func Test_EvaluateWithoutMetricValues(t *testing.T) {
+	deployment, _ := newTestDeployment([]testRole{{"web1", "web", powerStateStarted, 0}})
+	autoscaler, err := NewAutoscaler("service", "deployment", []AutoscaleRule{newTestRule("out", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 50)}, NewStaticMetricsSource())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	decisions, err := autoscaler.Evaluate(deployment, testNow)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !decisions[0].Skipped || decisions[0].Reason != noMetricValuesReason {
+		t.Errorf("Expected: %s, got: %s", noMetricValuesReason, decisions[0].Reason)
+	}
+}
+
+func Test_EvaluateOneActionPerAvailabilitySet(t *testing.T) {
+	roles := []testRole{
+		{"web1", "web", powerStateStarted, 90},
+		{"web2", "web", powerStateStopped, 0},
+		{"api1", "api", powerStateStarted, 90},
+		{"api2", "api", powerStateStopped, 0},
+	}
+
+	decisions := evaluateTestRules(t, roles,
+		newTestRule("web-out", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 80),
+		newTestRule("web-out-again", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 50),
+		newTestRule("api-out", "api", AutoscaleActionScaleOut, OperatorGreaterThan, 80))
+
+	expected := []struct {
+		skipped bool
+		reason  string
+	}{
+		{false, ""},
+		{true, availabilitySetScaledReason},
+		{false, ""},
+	}
+
+	for i, decision := range decisions {
+		if decision.Skipped != expected[i].skipped || decision.Reason != expected[i].reason {
+			t.Errorf("%s: expected skipped %v (%s), got %v (%s)", decision.Rule, expected[i].skipped, expected[i].reason, decision.Skipped, decision.Reason)
+		}
+		if decision.Skipped && len(decision.RoleNames) > 0 {
+			t.Errorf("%s: expected no roles, got %v", decision.Rule, decision.RoleNames)
+		}
+	}
+}
+
+func Test_EvaluateCooldownPerAvailabilitySet(t *testing.T) {
+	roles := []testRole{
+		{"web1", "web", powerStateStarted, 10},
+		{"web2", "web", powerStateStarted, 10},
+		{"api1", "api", powerStateStarted, 10},
+		{"api2", "api", powerStateStarted, 10},
+	}
+
+	deployment, metrics := newTestDeployment(roles)
+	rules := []AutoscaleRule{
+		newTestRule("web-in", "web", AutoscaleActionScaleIn, OperatorLessThan, 20),
+		newTestRule("api-in", "api", AutoscaleActionScaleIn, OperatorLessThan, 20),
+	}
+	autoscaler, err := NewAutoscaler("service", "deployment", rules, metrics)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Another rule scaled the web availability set out five minutes ago
+	autoscaler.lastAction["web"] = testNow.Add(-5 * time.Minute)
+
+	testCases := []struct {
+		now             time.Time
+		expectedSkipped []bool
+	}{
+		{testNow, []bool{true, false}},
+		{testNow.Add(10 * time.Minute), []bool{false, false}},
+	}
+
+	for _, testCase := range testCases {
+		metrics.AddPoints("web1", metricsClient.MetricPercentageCPU, metricsClient.MetricPoint{Timestamp: testCase.now.Add(-time.Minute), Average: 10, Count: 1})
+		metrics.AddPoints("web2", metricsClient.MetricPercentageCPU, metricsClient.MetricPoint{Timestamp: testCase.now.Add(-time.Minute), Average: 10, Count: 1})
+		metrics.AddPoints("api1", metricsClient.MetricPercentageCPU, metricsClient.MetricPoint{Timestamp: testCase.now.Add(-time.Minute), Average: 10, Count: 1})
+		metrics.AddPoints("api2", metricsClient.MetricPercentageCPU, metricsClient.MetricPoint{Timestamp: testCase.now.Add(-time.Minute), Average: 10, Count: 1})
+
+		decisions, err := autoscaler.Evaluate(deployment, testCase.now)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		for i, decision := range decisions {
+			if decision.Skipped != testCase.expectedSkipped[i] {
+				t.Errorf("%s at %s: expected skipped %v, got %v (%s)", decision.Rule, testCase.now, testCase.expectedSkipped[i], decision.Skipped, decision.Reason)
+			}
+		}
+	}
+
+	expectedReason := fmt.Sprintf(cooldownReason, testNow.Add(10*time.Minute).Format(time.RFC3339))
+	decisions, _ := autoscaler.Evaluate(deployment, testNow)
+	if decisions[0].Reason != expectedReason {
+		t.Errorf("Expected: %s, got: %s", expectedReason, decisions[0].Reason)
+	}
+}
+
+func Test_NewAutoscalerDefaults(t *testing.T) {
+	autoscaler, err := NewAutoscaler("service", "deployment", []AutoscaleRule{newTestRule("out", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 50)}, NewStaticMetricsSource())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if autoscaler.Output != nil {
+		t.Errorf("Expected: no output, got: %v", autoscaler.Output)
+	}
+	if autoscaler.ReadyTimeout != defaultReadyTimeout {
+		t.Errorf("Expected: %s, got: %s", defaultReadyTimeout, autoscaler.ReadyTimeout)
+	}
+}
+
+func Test_ParseAutoscaleRulesErrors(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected string
+	}{
+		{`[{"name":"out","availabilitySet":"web","window":"10m","action":"Grow","operator":">"}]`, fmt.Sprintf(invalidAutoscaleActionError, "Grow", "out")},
+		{`[{"name":"out","availabilitySet":"web","window":"10m","action":"ScaleOut","operator":"=="}]`, fmt.Sprintf(invalidAutoscaleOperatorError, "==", "out")},
+		{`[{"name":"out","availabilitySet":"web","window":"10m","action":"ScaleOut","operator":">","minInstances":3,"maxInstances":2}]`, fmt.Sprintf(invalidInstanceLimitsError, "out", 3, 2)},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseAutoscaleRules([]byte(testCase.data))
+		if err == nil || err.Error() != testCase.expected {
+			t.Errorf("Expected: %s, got: %v", testCase.expected, err)
+		}
+	}
+}
+
+func Test_applyDecisionsFailingHalfway(t *testing.T) {
+	roles := []testRole{
+		{"web1", "web", powerStateStarted, 90},
+		{"web2", "web", powerStateStopped, 0},
+		{"web3", "web", powerStateStopped, 0},
+		{"api1", "api", powerStateStarted, 90},
+		{"api2", "api", powerStateStopped, 0},
+	}
+
+	deployment, metrics := newTestDeployment(roles)
+	webOut := newTestRule("web-out", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 80)
+	webOut.InstanceCount = 2
+	rules := []AutoscaleRule{webOut, newTestRule("api-out", "api", AutoscaleActionScaleOut, OperatorGreaterThan, 80)}
+	autoscaler, err := NewAutoscaler("service", "deployment", rules, metrics)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	started := []string{}
+	autoscaler.roles = roleOperations{
+		start: func(cloudserviceName, deploymentName, roleName string) error {
+			started = append(started, roleName)
+			return nil
+		},
+		shutdown: func(cloudserviceName, deploymentName, roleName, postShutdownAction string) error {
+			return nil
+		},
+		waitForReady: func(cloudserviceName, deploymentName, roleName string, timeout time.Duration) error {
+			if roleName == "web2" {
+				return errors.New("timed out")
+			}
+			return nil
+		},
+	}
+
+	decisions, err := autoscaler.Evaluate(deployment, testNow)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	err = autoscaler.applyDecisions(decisions, testNow)
+	if err == nil || err.Error() != "web-out: timed out" {
+		t.Errorf("Expected: web-out: timed out, got: %v", err)
+	}
+	if !reflect.DeepEqual(started, []string{"web2", "api2"}) {
+		t.Errorf("Expected: [web2 api2] started, got: %v", started)
+	}
+
+	decisions, err = autoscaler.Evaluate(deployment, testNow.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expectedReason := fmt.Sprintf(cooldownReason, testNow.Add(15*time.Minute).Format(time.RFC3339))
+	for _, decision := range decisions {
+		if !decision.Skipped || decision.Reason != expectedReason {
+			t.Errorf("%s: expected: %s, got: %s", decision.Rule, expectedReason, decision.Reason)
+		}
+	}
+}
+
+func Test_applyDecisionsDryRun(t *testing.T) {
+	deployment, metrics := newTestDeployment([]testRole{{"web1", "web", powerStateStarted, 90}, {"web2", "web", powerStateStopped, 0}})
+	autoscaler, err := NewAutoscaler("service", "deployment", []AutoscaleRule{newTestRule("web-out", "web", AutoscaleActionScaleOut, OperatorGreaterThan, 80)}, metrics)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var output bytes.Buffer
+	autoscaler.DryRun = true
+	autoscaler.Output = &output
+	autoscaler.roles = roleOperations{}
+
+	decisions, _ := autoscaler.Evaluate(deployment, testNow)
+	err = autoscaler.applyDecisions(decisions, testNow)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	expected := "2015-01-01T12:00:00Z rule web-out: Percentage CPU 90.00 > 80.00, start web2\n"
+	if output.String() != expected {
+		t.Errorf("Expected: %q, got: %q", expected, output.String())
+	}
+	if autoscaler.lastAction["web"] != testNow {
+		t.Errorf("Expected: cooldown from %s, got: %s", testNow, autoscaler.lastAction["web"])
+	}
+}
@@ -0,0 +1,35 @@
+package autoscaleClient
+
+import (
+	"time"
+)
+
+type AutoscaleRule struct {
+	Name                string  `json:"name"`
+	AvailabilitySetName string  `json:"availabilitySet"`
+	Metric              string  `json:"metric"`
+	Aggregation         string  `json:"aggregation"`
+	Operator            string  `json:"operator"`
+	Threshold           float64 `json:"threshold"`
+	Window              string  `json:"window"`
+	Action              string  `json:"action"`
+	InstanceCount       int     `json:"instanceCount"`
+	MinInstances        int     `json:"minInstances"`
+	MaxInstances        int     `json:"maxInstances"`
+	Cooldown            string  `json:"cooldown"`
+	PostShutdownAction  string  `json:"postShutdownAction"`
+}
+
+type AutoscaleDecision struct {
+	Time                time.Time
+	Rule                string
+	AvailabilitySetName string
+	Action              string
+	RoleNames           []string
+	Metric              string
+	Value               float64
+	Operator            string
+	Threshold           float64
+	Skipped             bool
+	Reason              string
+}
@@ -0,0 +1,97 @@
+package autoscaleClient
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/metricsClient"
+)
+
+// MetricsSource supplies the metric values the autoscaler evaluates rules
+// against. Implementations other than the Azure one allow rules to be tried
+// offline with synthetic data.
+type MetricsSource interface {
+	GetRoleMetric(cloudserviceName, deploymentName, roleName, metricName string, startTime, endTime time.Time) ([]metricsClient.MetricPoint, error)
+}
+
+type AzureMetricsSource struct {
+	TimeGrain string
+}
+
+type StaticMetricsSource struct {
+	mutex  sync.Mutex
+	points map[string][]metricsClient.MetricPoint
+}
+
+//Region public methods starts
+
+func NewAzureMetricsSource(timeGrain string) *AzureMetricsSource {
+	if len(timeGrain) == 0 {
+		timeGrain = metricsClient.TimeGrain5Minutes
+	}
+
+	return &AzureMetricsSource{TimeGrain: timeGrain}
+}
+
+func (source *AzureMetricsSource) GetRoleMetric(cloudserviceName, deploymentName, roleName, metricName string, startTime, endTime time.Time) ([]metricsClient.MetricPoint, error) {
+	values, err := metricsClient.GetRoleMetricValues(cloudserviceName, deploymentName, roleName, []string{metricName}, source.TimeGrain, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	valueSet, err := values.FindMetric(metricName)
+	if err != nil {
+		return nil, err
+	}
+
+	return valueSet.MetricValues, nil
+}
+
+func NewStaticMetricsSource() *StaticMetricsSource {
+	return &StaticMetricsSource{points: make(map[string][]metricsClient.MetricPoint)}
+}
+
+// AddPoints records synthetic points for a role metric. Only the points whose
+// timestamps fall in the requested range are returned by GetRoleMetric.
+func (source *StaticMetricsSource) AddPoints(roleName, metricName string, points ...metricsClient.MetricPoint) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	key := getStaticMetricKey(roleName, metricName)
+	source.points[key] = append(source.points[key], points...)
+}
+
+func (source *StaticMetricsSource) GetRoleMetric(cloudserviceName, deploymentName, roleName, metricName string, startTime, endTime time.Time) ([]metricsClient.MetricPoint, error) {
+	if len(roleName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	}
+	if len(metricName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "metricName")
+	}
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	points := []metricsClient.MetricPoint{}
+	for _, point := range source.points[getStaticMetricKey(roleName, metricName)] {
+		if point.Timestamp.Before(startTime) || point.Timestamp.After(endTime) {
+			continue
+		}
+
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func getStaticMetricKey(roleName, metricName string) string {
+	return roleName + "/" + metricName
+}
+
+//Region private methods ends
@@ -0,0 +1,438 @@
+package deploymentClient
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/storage"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/storageServiceClient"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/vmClient"
+)
+
+const (
+	azureXmlns                      = "http://schemas.microsoft.com/windowsazure"
+	azureHostedServiceURL           = "services/hostedservices/%s"
+	azureDeploymentSlotURL          = "services/hostedservices/%s/deploymentslots/%s"
+	azureDeploymentUpgradeURL       = "services/hostedservices/%s/deploymentslots/%s/?comp=upgrade"
+	azureDeploymentWalkUpgradeURL   = "services/hostedservices/%s/deploymentslots/%s/?comp=walkupgradedomain"
+	azureDeploymentConfigurationURL = "services/hostedservices/%s/deploymentslots/%s/?comp=config"
+	azureDeploymentStatusURL        = "services/hostedservices/%s/deploymentslots/%s/?comp=status"
+
+	UpgradeModeAuto         = "Auto"
+	UpgradeModeManual       = "Manual"
+	UpgradeModeSimultaneous = "Simultaneous"
+
+	DeploymentStatusRunning   = "Running"
+	DeploymentStatusSuspended = "Suspended"
+
+	invalidDeploymentSlotError   = "Invalid deployment slot: %s. Valid values are 'Production' and 'Staging'"
+	invalidUpgradeModeError      = "Invalid upgrade mode: %s. Valid values are 'Auto', 'Manual' and 'Simultaneous'"
+	invalidDeploymentStatusError = "Invalid deployment status: %s. Valid values are 'Running' and 'Suspended'"
+	noStagingDeploymentError     = "Cloud service %s has no staging deployment to swap"
+)
+
+//Region public methods starts
+
+// UploadPackage uploads a local .cspkg file to container in the storage service
+// and returns the URL to pass as packageUrl.
+func UploadPackage(storageServiceName, container, packagePath string) (string, error) {
+	if len(storageServiceName) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "storageServiceName")
+	}
+	if len(container) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "container")
+	}
+	if len(packagePath) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "packagePath")
+	}
+
+	blobService, blobEndpoint, err := getBlobService(storageServiceName)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = blobService.CreateContainerIfNotExists(container, storage.ContainerAccessTypePrivate)
+	if err != nil {
+		return "", err
+	}
+
+	packageFile, err := os.Open(packagePath)
+	if err != nil {
+		return "", err
+	}
+	defer packageFile.Close()
+
+	blobName := filepath.Base(packagePath)
+	err = blobService.PutBlockBlob(container, blobName, packageFile)
+	if err != nil {
+		return "", err
+	}
+
+	return blobEndpoint + container + "/" + blobName, nil
+}
+
+func GetDeploymentBySlot(cloudserviceName, deploymentSlot string) (*Deployment, error) {
+	if len(cloudserviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+
+	err := verifyDeploymentSlot(deploymentSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := new(Deployment)
+
+	requestURL := fmt.Sprintf(azureDeploymentSlotURL, cloudserviceName, deploymentSlot)
+	response, err := azure.SendAzureGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+// CreateDeployment deploys the package at packageUrl with the service
+// configuration read from configurationPath to the given slot.
+func CreateDeployment(cloudserviceName, deploymentSlot, deploymentName, packageUrl, configurationPath, label string, startDeployment bool) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(packageUrl) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "packageUrl")
+	}
+	if len(configurationPath) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "configurationPath")
+	}
+
+	err := verifyDeploymentSlot(deploymentSlot)
+	if err != nil {
+		return err
+	}
+
+	configuration, err := readConfiguration(configurationPath)
+	if err != nil {
+		return err
+	}
+
+	if len(label) == 0 {
+		label = deploymentName
+	}
+
+	deploymentCreation := createDeploymentCreationConf(deploymentName, packageUrl, configuration, label, startDeployment)
+	deploymentBytes, err := xml.Marshal(deploymentCreation)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureDeploymentSlotURL, cloudserviceName, deploymentSlot)
+	requestId, err := azure.SendAzurePostRequest(requestURL, deploymentBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+// UpgradeDeployment starts an in-place upgrade of the deployment in the slot.
+// With UpgradeModeManual each upgrade domain has to be walked with WalkUpgradeDomain.
+// An empty roleToUpgrade upgrades every role.
+func UpgradeDeployment(cloudserviceName, deploymentSlot, mode, packageUrl, configurationPath, label, roleToUpgrade string, force bool) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(packageUrl) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "packageUrl")
+	}
+	if len(configurationPath) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "configurationPath")
+	}
+	if len(label) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "label")
+	}
+
+	err := verifyDeploymentSlot(deploymentSlot)
+	if err != nil {
+		return err
+	}
+
+	err = verifyUpgradeMode(mode)
+	if err != nil {
+		return err
+	}
+
+	configuration, err := readConfiguration(configurationPath)
+	if err != nil {
+		return err
+	}
+
+	deploymentUpgrade := createDeploymentUpgradeConf(mode, packageUrl, configuration, label, roleToUpgrade, force)
+	upgradeBytes, err := xml.Marshal(deploymentUpgrade)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureDeploymentUpgradeURL, cloudserviceName, deploymentSlot)
+	requestId, err := azure.SendAzurePostRequest(requestURL, upgradeBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func WalkUpgradeDomain(cloudserviceName, deploymentSlot string, upgradeDomain int) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+
+	err := verifyDeploymentSlot(deploymentSlot)
+	if err != nil {
+		return err
+	}
+
+	walkUpgradeDomain := UpgradeDomainWalk{}
+	walkUpgradeDomain.UpgradeDomain = upgradeDomain
+	walkUpgradeDomain.Xmlns = azureXmlns
+
+	walkBytes, err := xml.Marshal(walkUpgradeDomain)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureDeploymentWalkUpgradeURL, cloudserviceName, deploymentSlot)
+	requestId, err := azure.SendAzurePostRequest(requestURL, walkBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func ChangeDeploymentConfiguration(cloudserviceName, deploymentSlot, configurationPath, mode string) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(configurationPath) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "configurationPath")
+	}
+
+	err := verifyDeploymentSlot(deploymentSlot)
+	if err != nil {
+		return err
+	}
+
+	if len(mode) == 0 {
+		mode = UpgradeModeAuto
+	}
+	err = verifyUpgradeMode(mode)
+	if err != nil {
+		return err
+	}
+
+	configuration, err := readConfiguration(configurationPath)
+	if err != nil {
+		return err
+	}
+
+	configurationChange := createConfigurationChangeConf(configuration, mode)
+	changeBytes, err := xml.Marshal(configurationChange)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureDeploymentConfigurationURL, cloudserviceName, deploymentSlot)
+	requestId, err := azure.SendAzurePostRequest(requestURL, changeBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+// SwapDeployment swaps the virtual IPs of the staging and production
+// deployments. The production slot may be empty.
+func SwapDeployment(cloudserviceName string) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+
+	hostedService, err := vmClient.GetHostedService(cloudserviceName, true)
+	if err != nil {
+		return err
+	}
+
+	deploymentSwap, err := createDeploymentSwapConf(hostedService)
+	if err != nil {
+		return err
+	}
+
+	swapBytes, err := xml.Marshal(deploymentSwap)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureHostedServiceURL, cloudserviceName)
+	requestId, err := azure.SendAzurePostRequest(requestURL, swapBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func UpdateDeploymentStatus(cloudserviceName, deploymentSlot, status string) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if status != DeploymentStatusRunning && status != DeploymentStatusSuspended {
+		return fmt.Errorf(invalidDeploymentStatusError, status)
+	}
+
+	err := verifyDeploymentSlot(deploymentSlot)
+	if err != nil {
+		return err
+	}
+
+	statusUpdate := DeploymentStatusUpdate{}
+	statusUpdate.Status = status
+	statusUpdate.Xmlns = azureXmlns
+
+	statusBytes, err := xml.Marshal(statusUpdate)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureDeploymentStatusURL, cloudserviceName, deploymentSlot)
+	requestId, err := azure.SendAzurePostRequest(requestURL, statusBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func verifyDeploymentSlot(deploymentSlot string) error {
+	if deploymentSlot != vmClient.DeploymentSlotProduction && deploymentSlot != vmClient.DeploymentSlotStaging {
+		return fmt.Errorf(invalidDeploymentSlotError, deploymentSlot)
+	}
+
+	return nil
+}
+
+func verifyUpgradeMode(mode string) error {
+	if mode != UpgradeModeAuto && mode != UpgradeModeManual && mode != UpgradeModeSimultaneous {
+		return fmt.Errorf(invalidUpgradeModeError, mode)
+	}
+
+	return nil
+}
+
+func readConfiguration(configurationPath string) (string, error) {
+	configuration, err := ioutil.ReadFile(configurationPath)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(configuration), nil
+}
+
+func getBlobService(storageServiceName string) (*storage.BlobStorageClient, string, error) {
+	storageService, err := storageServiceClient.GetStorageServiceByName(storageServiceName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	blobEndpoint, err := storageServiceClient.GetBlobEndpoint(storageService)
+	if err != nil {
+		return nil, "", err
+	}
+
+	storageClient, err := storageServiceClient.GetStorageClient(storageService)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !strings.HasSuffix(blobEndpoint, "/") {
+		blobEndpoint += "/"
+	}
+
+	return storageClient.GetBlobService(), blobEndpoint, nil
+}
+
+func createDeploymentCreationConf(deploymentName, packageUrl, configuration, label string, startDeployment bool) DeploymentCreation {
+	deploymentCreation := DeploymentCreation{}
+
+	deploymentCreation.Name = deploymentName
+	deploymentCreation.PackageUrl = packageUrl
+	deploymentCreation.Label = base64.StdEncoding.EncodeToString([]byte(label))
+	deploymentCreation.Configuration = configuration
+	deploymentCreation.StartDeployment = startDeployment
+	deploymentCreation.Xmlns = azureXmlns
+
+	return deploymentCreation
+}
+
+func createDeploymentUpgradeConf(mode, packageUrl, configuration, label, roleToUpgrade string, force bool) DeploymentUpgrade {
+	deploymentUpgrade := DeploymentUpgrade{}
+
+	deploymentUpgrade.Mode = mode
+	deploymentUpgrade.PackageUrl = packageUrl
+	deploymentUpgrade.Configuration = configuration
+	deploymentUpgrade.Label = base64.StdEncoding.EncodeToString([]byte(label))
+	deploymentUpgrade.RoleToUpgrade = roleToUpgrade
+	deploymentUpgrade.Force = force
+	deploymentUpgrade.Xmlns = azureXmlns
+
+	return deploymentUpgrade
+}
+
+func createConfigurationChangeConf(configuration, mode string) ConfigurationChange {
+	configurationChange := ConfigurationChange{}
+
+	configurationChange.Configuration = configuration
+	configurationChange.Mode = mode
+	configurationChange.Xmlns = azureXmlns
+
+	return configurationChange
+}
+
+func createDeploymentSwapConf(hostedService *vmClient.HostedService) (DeploymentSwap, error) {
+	deploymentSwap := DeploymentSwap{}
+	deploymentSwap.Xmlns = azureXmlns
+
+	for _, deployment := range hostedService.Deployments {
+		switch deployment.DeploymentSlot {
+		case vmClient.DeploymentSlotProduction:
+			deploymentSwap.Production = deployment.Name
+		case vmClient.DeploymentSlotStaging:
+			deploymentSwap.SourceDeployment = deployment.Name
+		}
+	}
+
+	if len(deploymentSwap.SourceDeployment) == 0 {
+		return deploymentSwap, fmt.Errorf(noStagingDeploymentError, hostedService.ServiceName)
+	}
+
+	return deploymentSwap, nil
+}
+
+//Region private methods ends
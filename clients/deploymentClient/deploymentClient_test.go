@@ -0,0 +1,201 @@
+package deploymentClient
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/vmClient"
+)
+
+func Test_createDeploymentCreationConf(t *testing.T) {
+	deploymentCreation := createDeploymentCreationConf("web-v2", "https://store.blob.core.windows.net/packages/web.cspkg", "PENvbmZpZy8+", "web v2", true)
+
+	output, err := xml.Marshal(deploymentCreation)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := `<CreateDeployment xmlns="http://schemas.microsoft.com/windowsazure">` +
+		`<Name>web-v2</Name>` +
+		`<PackageUrl>https://store.blob.core.windows.net/packages/web.cspkg</PackageUrl>` +
+		`<Label>d2ViIHYy</Label>` +
+		`<Configuration>PENvbmZpZy8+</Configuration>` +
+		`<StartDeployment>true</StartDeployment>` +
+		`<TreatWarningsAsError>false</TreatWarningsAsError>` +
+		`</CreateDeployment>`
+	if string(output) != expected {
+		t.Errorf("Expected: %s, got: %s", expected, output)
+	}
+}
+
+func Test_createDeploymentUpgradeConf(t *testing.T) {
+	testCases := []struct {
+		roleToUpgrade string
+		force         bool
+		expected      string
+	}{
+		{"", false, `<UpgradeDeployment xmlns="http://schemas.microsoft.com/windowsazure">` +
+			`<Mode>Manual</Mode>` +
+			`<PackageUrl>https://store.blob.core.windows.net/packages/web.cspkg</PackageUrl>` +
+			`<Configuration>PENvbmZpZy8+</Configuration>` +
+			`<Label>d2ViIHYy</Label>` +
+			`<Force>false</Force>` +
+			`</UpgradeDeployment>`},
+		{"WebRole", true, `<UpgradeDeployment xmlns="http://schemas.microsoft.com/windowsazure">` +
+			`<Mode>Manual</Mode>` +
+			`<PackageUrl>https://store.blob.core.windows.net/packages/web.cspkg</PackageUrl>` +
+			`<Configuration>PENvbmZpZy8+</Configuration>` +
+			`<Label>d2ViIHYy</Label>` +
+			`<RoleToUpgrade>WebRole</RoleToUpgrade>` +
+			`<Force>true</Force>` +
+			`</UpgradeDeployment>`},
+	}
+
+	for _, testCase := range testCases {
+		deploymentUpgrade := createDeploymentUpgradeConf(UpgradeModeManual, "https://store.blob.core.windows.net/packages/web.cspkg", "PENvbmZpZy8+", "web v2", testCase.roleToUpgrade, testCase.force)
+
+		output, err := xml.Marshal(deploymentUpgrade)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(output) != testCase.expected {
+			t.Errorf("Expected: %s, got: %s", testCase.expected, output)
+		}
+	}
+}
+
+func Test_createConfigurationChangeConf(t *testing.T) {
+	output, err := xml.Marshal(createConfigurationChangeConf("PENvbmZpZy8+", UpgradeModeAuto))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := `<ChangeConfiguration xmlns="http://schemas.microsoft.com/windowsazure">` +
+		`<Configuration>PENvbmZpZy8+</Configuration>` +
+		`<TreatWarningsAsError>false</TreatWarningsAsError>` +
+		`<Mode>Auto</Mode>` +
+		`</ChangeConfiguration>`
+	if string(output) != expected {
+		t.Errorf("Expected: %s, got: %s", expected, output)
+	}
+}
+
+func Test_createDeploymentSwapConf(t *testing.T) {
+	hostedService := &vmClient.HostedService{ServiceName: "web"}
+	hostedService.Deployments = []vmClient.VMDeployment{
+		{Name: "web-v1", DeploymentSlot: vmClient.DeploymentSlotProduction},
+		{Name: "web-v2", DeploymentSlot: vmClient.DeploymentSlotStaging},
+	}
+
+	deploymentSwap, err := createDeploymentSwapConf(hostedService)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	output, err := xml.Marshal(deploymentSwap)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := `<Swap xmlns="http://schemas.microsoft.com/windowsazure">` +
+		`<Production>web-v1</Production>` +
+		`<SourceDeployment>web-v2</SourceDeployment>` +
+		`</Swap>`
+	if string(output) != expected {
+		t.Errorf("Expected: %s, got: %s", expected, output)
+	}
+}
+
+func Test_createDeploymentSwapConfWithoutStaging(t *testing.T) {
+	hostedService := &vmClient.HostedService{ServiceName: "web"}
+	hostedService.Deployments = []vmClient.VMDeployment{
+		{Name: "web-v1", DeploymentSlot: vmClient.DeploymentSlotProduction},
+	}
+
+	_, err := createDeploymentSwapConf(hostedService)
+
+	expected := "Cloud service web has no staging deployment to swap"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, err)
+	}
+}
+
+func Test_verifyDeploymentSlot(t *testing.T) {
+	testCases := []struct {
+		deploymentSlot string
+		valid          bool
+	}{
+		{vmClient.DeploymentSlotProduction, true},
+		{vmClient.DeploymentSlotStaging, true},
+		{"production", false},
+		{"", false},
+	}
+
+	for _, testCase := range testCases {
+		err := verifyDeploymentSlot(testCase.deploymentSlot)
+		if (err == nil) != testCase.valid {
+			t.Errorf("verifyDeploymentSlot(%s): expected valid %v, got %v", testCase.deploymentSlot, testCase.valid, err)
+		}
+	}
+
+	expected := "Invalid deployment slot: Preview. Valid values are 'Production' and 'Staging'"
+	if err := verifyDeploymentSlot("Preview"); err == nil || err.Error() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, err)
+	}
+}
+
+func Test_verifyUpgradeMode(t *testing.T) {
+	testCases := []struct {
+		mode  string
+		valid bool
+	}{
+		{UpgradeModeAuto, true},
+		{UpgradeModeManual, true},
+		{UpgradeModeSimultaneous, true},
+		{"auto", false},
+		{"", false},
+	}
+
+	for _, testCase := range testCases {
+		err := verifyUpgradeMode(testCase.mode)
+		if (err == nil) != testCase.valid {
+			t.Errorf("verifyUpgradeMode(%s): expected valid %v, got %v", testCase.mode, testCase.valid, err)
+		}
+	}
+
+	expected := "Invalid upgrade mode: Rolling. Valid values are 'Auto', 'Manual' and 'Simultaneous'"
+	if err := verifyUpgradeMode("Rolling"); err == nil || err.Error() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, err)
+	}
+}
+
+func Test_readConfiguration(t *testing.T) {
+	configurationFile, err := ioutil.TempFile("", "ServiceConfiguration")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer os.Remove(configurationFile.Name())
+
+	_, err = configurationFile.WriteString(`<ServiceConfiguration serviceName="web" />`)
+	configurationFile.Close()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	configuration, err := readConfiguration(configurationFile.Name())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := "PFNlcnZpY2VDb25maWd1cmF0aW9uIHNlcnZpY2VOYW1lPSJ3ZWIiIC8+"
+	if configuration != expected {
+		t.Errorf("Expected: %s, got: %s", expected, configuration)
+	}
+
+	_, err = readConfiguration(configurationFile.Name() + ".missing")
+	if err == nil {
+		t.Errorf("Expected: error for a missing configuration file")
+	}
+}
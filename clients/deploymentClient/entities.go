@@ -0,0 +1,107 @@
+package deploymentClient
+
+import (
+	"encoding/xml"
+)
+
+type Deployment struct {
+	XMLName            xml.Name `xml:"Deployment"`
+	Xmlns              string   `xml:"xmlns,attr"`
+	Name               string
+	DeploymentSlot     string
+	PrivateID          string
+	Status             string
+	Label              string
+	Url                string
+	Configuration      string
+	RoleInstanceList   []RoleInstance `xml:"RoleInstanceList>RoleInstance"`
+	UpgradeStatus      *UpgradeStatus
+	UpgradeDomainCount int
+	SdkVersion         string
+	Locked             bool
+	RollbackAllowed    bool
+	CreatedTime        string
+	LastModifiedTime   string
+	ExtendedProperties []ExtendedProperty `xml:"ExtendedProperties>ExtendedProperty"`
+	VirtualIPs         []VirtualIP        `xml:"VirtualIPs>VirtualIP"`
+}
+
+type VirtualIP struct {
+	Address         string
+	IsDnsProgrammed bool
+	Name            string
+}
+
+type RoleInstance struct {
+	RoleName              string
+	InstanceName          string
+	InstanceStatus        string
+	InstanceUpgradeDomain int
+	InstanceFaultDomain   int
+	InstanceSize          string
+	InstanceStateDetails  string
+	InstanceErrorCode     string
+	IpAddress             string
+	PowerState            string
+	HostName              string
+}
+
+type UpgradeStatus struct {
+	UpgradeType               string
+	CurrentUpgradeDomainState string
+	CurrentUpgradeDomain      int
+}
+
+type ExtendedProperty struct {
+	Name  string
+	Value string
+}
+
+type DeploymentCreation struct {
+	XMLName              xml.Name `xml:"CreateDeployment"`
+	Xmlns                string   `xml:"xmlns,attr"`
+	Name                 string
+	PackageUrl           string
+	Label                string
+	Configuration        string
+	StartDeployment      bool
+	TreatWarningsAsError bool
+}
+
+type DeploymentUpgrade struct {
+	XMLName       xml.Name `xml:"UpgradeDeployment"`
+	Xmlns         string   `xml:"xmlns,attr"`
+	Mode          string
+	PackageUrl    string
+	Configuration string
+	Label         string
+	RoleToUpgrade string `xml:",omitempty"`
+	Force         bool
+}
+
+type UpgradeDomainWalk struct {
+	XMLName       xml.Name `xml:"WalkUpgradeDomain"`
+	Xmlns         string   `xml:"xmlns,attr"`
+	UpgradeDomain int
+}
+
+type ConfigurationChange struct {
+	XMLName              xml.Name `xml:"ChangeConfiguration"`
+	Xmlns                string   `xml:"xmlns,attr"`
+	Configuration        string
+	TreatWarningsAsError bool
+	Mode                 string
+}
+
+type DeploymentSwap struct {
+	XMLName          xml.Name `xml:"Swap"`
+	Xmlns            string   `xml:"xmlns,attr"`
+	Production       string
+	SourceDeployment string
+}
+
+type DeploymentStatusUpdate struct {
+	XMLName xml.Name `xml:"UpdateDeploymentStatus"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Status  string
+}
@@ -11,12 +11,130 @@ type ImageList struct {
 }
 
 type OSImage struct {
-	Category        string
-	Label           string
-	LogicalSizeInGB string
-	Name            string
-	OS              string
-	Eula            string
-	Description     string
-	Location        string
+	Category          string
+	Label             string
+	LogicalSizeInGB   string
+	Name              string
+	OS                string
+	Eula              string
+	Description       string
+	Location          string
+	MediaLink         string
+	ImageFamily       string
+	PublishedDate     string
+	PublisherName     string
+	RecommendedVMSize string
+	IsPremium         bool
+	ShowInGui         bool
+	IconUri           string
+	SmallIconUri      string
+	PrivacyUri        string
+}
+
+type ImageQuery struct {
+	OS           string
+	Publisher    string
+	Family       string
+	LabelPattern string
+	Location     string
+	Category     string
+}
+
+type OSImageDeployment struct {
+	XMLName           xml.Name `xml:"OSImage"`
+	Xmlns             string   `xml:"xmlns,attr"`
+	Label             string
+	MediaLink         string
+	Name              string
+	OS                string
+	Eula              string `xml:",omitempty"`
+	Description       string `xml:",omitempty"`
+	RecommendedVMSize string `xml:",omitempty"`
+}
+
+type OSImageUpdate struct {
+	XMLName           xml.Name `xml:"OSImage"`
+	Xmlns             string   `xml:"xmlns,attr"`
+	Label             string
+	Eula              string `xml:",omitempty"`
+	Description       string `xml:",omitempty"`
+	RecommendedVMSize string `xml:",omitempty"`
+}
+
+type VMImageList struct {
+	XMLName  xml.Name  `xml:"VMImages"`
+	Xmlns    string    `xml:"xmlns,attr"`
+	VMImages []VMImage `xml:"VMImage"`
+}
+
+type VMImage struct {
+	Name                   string
+	Label                  string
+	Category               string
+	Description            string
+	OSDiskConfiguration    OSDiskConfiguration
+	DataDiskConfigurations []DataDiskConfiguration `xml:"DataDiskConfigurations>DataDiskConfiguration"`
+	ServiceName            string
+	DeploymentName         string
+	RoleName               string
+	Location               string
+	AffinityGroup          string
+	CreatedTime            string
+	ModifiedTime           string
+	Language               string
+	ImageFamily            string
+	RecommendedVMSize      string
+	IsPremium              bool
+	Eula                   string
+	IconUri                string
+	SmallIconUri           string
+	PrivacyUri             string
+	PublisherName          string
+	PublishedDate          string
+	ShowInGui              bool
+}
+
+type OSDiskConfiguration struct {
+	Name                string `xml:",omitempty"`
+	HostCaching         string `xml:",omitempty"`
+	OSState             string `xml:",omitempty"`
+	OS                  string `xml:",omitempty"`
+	MediaLink           string `xml:",omitempty"`
+	LogicalDiskSizeInGB int    `xml:",omitempty"`
+}
+
+type DataDiskConfiguration struct {
+	Name                string `xml:",omitempty"`
+	HostCaching         string `xml:",omitempty"`
+	Lun                 int
+	MediaLink           string `xml:",omitempty"`
+	LogicalDiskSizeInGB int    `xml:",omitempty"`
+}
+
+type VMImageDeployment struct {
+	XMLName                xml.Name `xml:"VMImage"`
+	Xmlns                  string   `xml:"xmlns,attr"`
+	Name                   string
+	Label                  string
+	Description            string `xml:",omitempty"`
+	OSDiskConfiguration    OSDiskConfiguration
+	DataDiskConfigurations []DataDiskConfiguration `xml:"DataDiskConfigurations>DataDiskConfiguration,omitempty"`
+	Language               string                  `xml:",omitempty"`
+	ImageFamily            string                  `xml:",omitempty"`
+	RecommendedVMSize      string                  `xml:",omitempty"`
+	Eula                   string                  `xml:",omitempty"`
+	ShowInGui              bool
+}
+
+type VMImageUpdate struct {
+	XMLName                xml.Name `xml:"VMImage"`
+	Xmlns                  string   `xml:"xmlns,attr"`
+	Label                  string
+	OSDiskConfiguration    *OSDiskConfiguration
+	DataDiskConfigurations []DataDiskConfiguration `xml:"DataDiskConfigurations>DataDiskConfiguration,omitempty"`
+	Description            string                  `xml:",omitempty"`
+	Language               string                  `xml:",omitempty"`
+	ImageFamily            string                  `xml:",omitempty"`
+	RecommendedVMSize      string                  `xml:",omitempty"`
+	Eula                   string                  `xml:",omitempty"`
 }
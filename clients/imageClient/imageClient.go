@@ -8,24 +8,118 @@ import (
 )
 
 const (
-	azureImageListURL = "services/images"
+	azureXmlns          = "http://schemas.microsoft.com/windowsazure"
+	azureImageListURL   = "services/images"
+	azureImageURL       = "services/images/%s"
+	deleteAzureImageURL = "services/images/%s?comp=media"
+
+	osLinux   = "Linux"
+	osWindows = "Windows"
+
 	invalidImageError = "Can not find image %s in specified subscription, please specify another image name."
+	invalidOSError    = "You must specify correct OS param. Valid values are 'Linux' and 'Windows'"
 )
 
 func GetImageList() (ImageList, error) {
-	imageList := ImageList{}
+	return getImageList(false)
+}
+
+func GetImage(imageName string) (*OSImage, error) {
+	if len(imageName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "imageName")
+	}
+
+	image := new(OSImage)
 
-	response, err := azure.SendAzureGetRequest(azureImageListURL)
+	requestURL := fmt.Sprintf(azureImageURL, imageName)
+	response, err := azure.SendAzureGetRequest(requestURL)
 	if err != nil {
-		return imageList, err
+		return nil, err
 	}
 
-	err = xml.Unmarshal(response, &imageList)
+	err = xml.Unmarshal(response, image)
 	if err != nil {
-		return imageList, err
+		return nil, err
+	}
+
+	return image, nil
+}
+
+func CreateImage(imageName, label, mediaLink, os, description, eula, recommendedVMSize string) (*OSImage, error) {
+	if len(imageName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "imageName")
+	}
+	if len(label) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "label")
+	}
+	if len(mediaLink) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "mediaLink")
+	}
+	if os != osLinux && os != osWindows {
+		return nil, errors.New(invalidOSError)
 	}
-	
-	return imageList, err
+
+	imageDeployment := createOSImageDeploymentConfig(imageName, label, mediaLink, os, description, eula, recommendedVMSize)
+	imageDeploymentBytes, err := xml.Marshal(imageDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	requestId, err := azure.SendAzurePostRequest(azureImageListURL, imageDeploymentBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer azure.InvalidateCatalogCache(azureImageListURL)
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetImage(imageName)
+}
+
+func UpdateImage(imageName, label, description, eula, recommendedVMSize string) error {
+	if len(imageName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "imageName")
+	}
+	if len(label) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "label")
+	}
+
+	imageUpdate := createOSImageUpdateConfig(label, description, eula, recommendedVMSize)
+	imageUpdateBytes, err := xml.Marshal(imageUpdate)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureImageURL, imageName)
+	requestId, err := azure.SendAzurePutRequest(requestURL, imageUpdateBytes)
+	if err != nil {
+		return err
+	}
+	defer azure.InvalidateCatalogCache(azureImageListURL)
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func DeleteImage(imageName string, deleteVHD bool) error {
+	if len(imageName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "imageName")
+	}
+
+	requestURL := fmt.Sprintf(azureImageURL, imageName)
+	if deleteVHD {
+		requestURL = fmt.Sprintf(deleteAzureImageURL, imageName)
+	}
+
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		return err
+	}
+	defer azure.InvalidateCatalogCache(azureImageListURL)
+
+	return azure.WaitAsyncOperation(requestId)
 }
 
 func ResolveImageName(imageName string) error {
@@ -33,7 +127,7 @@ func ResolveImageName(imageName string) error {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "imageName")
 	}
 
-	imageList, err := GetImageList()
+	imageList, err := getImageList(true)
 	if err != nil {
 		return err
 	}
@@ -48,3 +142,44 @@ func ResolveImageName(imageName string) error {
 
 	return errors.New(fmt.Sprintf(invalidImageError, imageName))
 }
+
+func getImageList(cached bool) (ImageList, error) {
+	imageList := ImageList{}
+
+	response, err := azure.SendAzureGetRequestWithCache(azureImageListURL, cached)
+	if err != nil {
+		return imageList, err
+	}
+
+	err = xml.Unmarshal(response, &imageList)
+	if err != nil {
+		return imageList, err
+	}
+
+	return imageList, nil
+}
+
+func createOSImageDeploymentConfig(imageName, label, mediaLink, os, description, eula, recommendedVMSize string) OSImageDeployment {
+	imageDeployment := OSImageDeployment{}
+	imageDeployment.Xmlns = azureXmlns
+	imageDeployment.Name = imageName
+	imageDeployment.Label = label
+	imageDeployment.MediaLink = mediaLink
+	imageDeployment.OS = os
+	imageDeployment.Description = description
+	imageDeployment.Eula = eula
+	imageDeployment.RecommendedVMSize = recommendedVMSize
+
+	return imageDeployment
+}
+
+func createOSImageUpdateConfig(label, description, eula, recommendedVMSize string) OSImageUpdate {
+	imageUpdate := OSImageUpdate{}
+	imageUpdate.Xmlns = azureXmlns
+	imageUpdate.Label = label
+	imageUpdate.Description = description
+	imageUpdate.Eula = eula
+	imageUpdate.RecommendedVMSize = recommendedVMSize
+
+	return imageUpdate
+}
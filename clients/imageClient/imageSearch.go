@@ -0,0 +1,185 @@
+package imageClient
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	imageNotFoundInFamilyError         = "Can not find any image in family %s"
+	imageNotFoundInFamilyLocationError = "Can not find any image in family %s available in %s"
+	imageNotInLocationError            = "Image %s is not available in %s"
+)
+
+//Region public methods starts
+
+// FindImages returns the images matching every non empty field of query,
+// newest first. LabelPattern is a regular expression, the other fields are
+// compared case insensitively.
+func FindImages(query ImageQuery) ([]OSImage, error) {
+	var labelPattern *regexp.Regexp
+	if len(query.LabelPattern) > 0 {
+		var err error
+		labelPattern, err = regexp.Compile(query.LabelPattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	imageList, err := GetImageList()
+	if err != nil {
+		return nil, err
+	}
+
+	return findImages(imageList.OSImages, query, labelPattern), nil
+}
+
+// LatestImage returns the most recently published image of the family that
+// is available in location. An empty location matches every location.
+func LatestImage(family, location string) (*OSImage, error) {
+	if len(family) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "family")
+	}
+
+	imageList, err := GetImageList()
+	if err != nil {
+		return nil, err
+	}
+
+	return latestImage(imageList.OSImages, family, location)
+}
+
+// ResolveImage returns the name of the image identified by an exact image
+// name, an image label or an image family, in that order. For a label or a
+// family the most recently published matching image available in location is
+// used. An empty location matches every location.
+func ResolveImage(image, location string) (string, error) {
+	if len(image) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "image")
+	}
+
+	imageList, err := getImageList(true)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveImage(imageList.OSImages, image, location)
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func findImages(images []OSImage, query ImageQuery, labelPattern *regexp.Regexp) []OSImage {
+	matchingImages := []OSImage{}
+	for _, image := range images {
+		if !matchImageField(image.OS, query.OS) ||
+			!matchImageField(image.PublisherName, query.Publisher) ||
+			!matchImageField(image.ImageFamily, query.Family) ||
+			!matchImageField(image.Category, query.Category) ||
+			!matchImageLocation(image.Location, query.Location) {
+			continue
+		}
+		if labelPattern != nil && !labelPattern.MatchString(image.Label) {
+			continue
+		}
+
+		matchingImages = append(matchingImages, image)
+	}
+
+	sort.Stable(imagesByPublishedDate(matchingImages))
+	return matchingImages
+}
+
+func latestImage(images []OSImage, family, location string) (*OSImage, error) {
+	matchingImages := findImages(images, ImageQuery{Family: family, Location: location}, nil)
+	if len(matchingImages) == 0 {
+		if len(location) > 0 {
+			return nil, errors.New(fmt.Sprintf(imageNotFoundInFamilyLocationError, family, location))
+		}
+		return nil, errors.New(fmt.Sprintf(imageNotFoundInFamilyError, family))
+	}
+
+	return &matchingImages[0], nil
+}
+
+func resolveImage(images []OSImage, image, location string) (string, error) {
+	for _, existingImage := range images {
+		if existingImage.Name != image {
+			continue
+		}
+		if !matchImageLocation(existingImage.Location, location) {
+			return "", errors.New(fmt.Sprintf(imageNotInLocationError, image, location))
+		}
+
+		return existingImage.Name, nil
+	}
+
+	sortedImages := make(imagesByPublishedDate, len(images))
+	copy(sortedImages, images)
+	sort.Stable(sortedImages)
+
+	// A label match wins over a family match, even an older one
+	for _, existingImage := range sortedImages {
+		if existingImage.Label == image && matchImageLocation(existingImage.Location, location) {
+			return existingImage.Name, nil
+		}
+	}
+
+	for _, existingImage := range sortedImages {
+		if strings.EqualFold(existingImage.ImageFamily, image) && matchImageLocation(existingImage.Location, location) {
+			return existingImage.Name, nil
+		}
+	}
+
+	return "", errors.New(fmt.Sprintf(invalidImageError, image))
+}
+
+type imagesByPublishedDate []OSImage
+
+func (images imagesByPublishedDate) Len() int {
+	return len(images)
+}
+
+func (images imagesByPublishedDate) Swap(i, j int) {
+	images[i], images[j] = images[j], images[i]
+}
+
+func (images imagesByPublishedDate) Less(i, j int) bool {
+	return parsePublishedDate(images[i].PublishedDate).After(parsePublishedDate(images[j].PublishedDate))
+}
+
+func parsePublishedDate(publishedDate string) time.Time {
+	date, err := time.Parse(time.RFC3339, publishedDate)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return date
+}
+
+func matchImageField(value, query string) bool {
+	return len(query) == 0 || strings.EqualFold(value, query)
+}
+
+func matchImageLocation(locations, location string) bool {
+	if len(location) == 0 {
+		return true
+	}
+
+	for _, imageLocation := range strings.Split(locations, ";") {
+		if strings.EqualFold(strings.TrimSpace(imageLocation), location) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Region private methods ends
@@ -0,0 +1,150 @@
+package imageClient
+
+import (
+	"regexp"
+	"testing"
+)
+
+var testImages = []OSImage{
+	{Name: "ubuntu-1404-old", Label: "Ubuntu Server 14.04 LTS", ImageFamily: "Ubuntu Server 14.04 LTS", OS: "Linux", PublisherName: "Canonical", Category: "Public", Location: "West US;East US", PublishedDate: "2015-01-10T00:00:00Z"},
+	{Name: "ubuntu-1404-undated", Label: "Ubuntu Server 14.04 LTS", ImageFamily: "Ubuntu Server 14.04 LTS", OS: "Linux", PublisherName: "Canonical", Category: "Public", Location: "West US;East US", PublishedDate: "January 2015"},
+	{Name: "ubuntu-1404-new", Label: "Ubuntu Server 14.04.2 LTS", ImageFamily: "Ubuntu Server 14.04 LTS", OS: "Linux", PublisherName: "Canonical", Category: "Public", Location: "West US", PublishedDate: "2015-03-01T00:00:00Z"},
+	{Name: "windows-2012", Label: "Windows Server 2012 R2 Datacenter", ImageFamily: "Windows Server 2012 R2 Datacenter", OS: "Windows", PublisherName: "Microsoft Windows Server Group", Category: "Public", Location: "West US;East US", PublishedDate: "2015-02-11T08:00:00Z"},
+	{Name: "Windows Server 2012 R2 Datacenter", Label: "custom", ImageFamily: "custom", OS: "Windows", Category: "User", Location: "East US", PublishedDate: "2014-06-01T00:00:00Z"},
+	{Name: "labelled-old", Label: "ubuntu server 14.04 lts", ImageFamily: "Other", OS: "Linux", Category: "Public", Location: "West US", PublishedDate: "2014-01-01T00:00:00Z"},
+}
+
+func Test_matchImageLocation(t *testing.T) {
+	testCases := []struct {
+		locations string
+		location  string
+		expected  bool
+	}{
+		{"West US;East US", "", true},
+		{"West US;East US", "East US", true},
+		{"West US; East US", "east us", true},
+		{"West US;East US", "North Europe", false},
+		{"", "West US", false},
+	}
+
+	for _, testCase := range testCases {
+		if output := matchImageLocation(testCase.locations, testCase.location); output != testCase.expected {
+			t.Errorf("matchImageLocation(%s, %s): expected %v, got %v", testCase.locations, testCase.location, testCase.expected, output)
+		}
+	}
+}
+
+func Test_imagesByPublishedDate(t *testing.T) {
+	images := findImages(testImages, ImageQuery{OS: "linux"}, nil)
+
+	expected := []string{"ubuntu-1404-new", "ubuntu-1404-old", "labelled-old", "ubuntu-1404-undated"}
+	if len(images) != len(expected) {
+		t.Fatalf("Expected: %d images, got: %d", len(expected), len(images))
+	}
+	for i, image := range images {
+		if image.Name != expected[i] {
+			t.Errorf("Expected: %s at %d, got: %s", expected[i], i, image.Name)
+		}
+	}
+}
+
+func Test_findImages(t *testing.T) {
+	testCases := []struct {
+		query        ImageQuery
+		labelPattern string
+		expected     []string
+	}{
+		{ImageQuery{Publisher: "canonical", Location: "East US"}, "", []string{"ubuntu-1404-old", "ubuntu-1404-undated"}},
+		{ImageQuery{Family: "windows server 2012 r2 datacenter"}, "", []string{"windows-2012"}},
+		{ImageQuery{Category: "User"}, "", []string{"Windows Server 2012 R2 Datacenter"}},
+		{ImageQuery{OS: "Linux"}, `^Ubuntu Server 14\.04\.\d+`, []string{"ubuntu-1404-new"}},
+		{ImageQuery{OS: "Linux", Location: "North Europe"}, "", []string{}},
+	}
+
+	for _, testCase := range testCases {
+		var labelPattern *regexp.Regexp
+		if len(testCase.labelPattern) > 0 {
+			labelPattern = regexp.MustCompile(testCase.labelPattern)
+		}
+
+		images := findImages(testImages, testCase.query, labelPattern)
+		if len(images) != len(testCase.expected) {
+			t.Errorf("Query %+v: expected: %v, got: %d images", testCase.query, testCase.expected, len(images))
+			continue
+		}
+		for i, image := range images {
+			if image.Name != testCase.expected[i] {
+				t.Errorf("Query %+v: expected: %s at %d, got: %s", testCase.query, testCase.expected[i], i, image.Name)
+			}
+		}
+	}
+}
+
+func Test_latestImage(t *testing.T) {
+	testCases := []struct {
+		family   string
+		location string
+		expected string
+	}{
+		{"Ubuntu Server 14.04 LTS", "", "ubuntu-1404-new"},
+		{"ubuntu server 14.04 lts", "East US", "ubuntu-1404-old"},
+		{"Ubuntu Server 14.04 LTS", "North Europe", "Can not find any image in family Ubuntu Server 14.04 LTS available in North Europe"},
+		{"CentOS", "", "Can not find any image in family CentOS"},
+	}
+
+	for _, testCase := range testCases {
+		image, err := latestImage(testImages, testCase.family, testCase.location)
+		if err != nil {
+			if err.Error() != testCase.expected {
+				t.Errorf("Expected: %s, got: %s", testCase.expected, err)
+			}
+			continue
+		}
+		if image.Name != testCase.expected {
+			t.Errorf("Expected: %s, got: %s", testCase.expected, image.Name)
+		}
+	}
+}
+
+func Test_resolveImage(t *testing.T) {
+	testCases := []struct {
+		image    string
+		location string
+		expected string
+	}{
+		// An exact name wins over the family of the same name
+		{"Windows Server 2012 R2 Datacenter", "", "Windows Server 2012 R2 Datacenter"},
+		{"Windows Server 2012 R2 Datacenter", "West US", "Image Windows Server 2012 R2 Datacenter is not available in West US"},
+		// A label wins over a newer image of the family of the same name
+		{"Ubuntu Server 14.04 LTS", "", "ubuntu-1404-old"},
+		{"Ubuntu Server 14.04 LTS", "East US", "ubuntu-1404-old"},
+		// Families are compared case insensitively, labels are not
+		{"ubuntu server 14.04 lts", "", "labelled-old"},
+		{"ubuntu server 14.04 lts", "East US", "ubuntu-1404-old"},
+		{"windows server 2012 r2 datacenter", "West US", "windows-2012"},
+		{"CentOS", "", "Can not find image CentOS in specified subscription, please specify another image name."},
+	}
+
+	for _, testCase := range testCases {
+		output, err := resolveImage(testImages, testCase.image, testCase.location)
+		if err != nil {
+			output = err.Error()
+		}
+		if output != testCase.expected {
+			t.Errorf("resolveImage(%s, %s): expected %s, got %s", testCase.image, testCase.location, testCase.expected, output)
+		}
+	}
+}
+
+func Test_resolveImageKeepsImageOrder(t *testing.T) {
+	images := make([]OSImage, len(testImages))
+	copy(images, testImages)
+
+	resolveImage(images, "Ubuntu Server 14.04 LTS", "")
+
+	for i, image := range images {
+		if image.Name != testImages[i].Name {
+			t.Errorf("Expected: %s at %d, got: %s", testImages[i].Name, i, image.Name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package imageClient
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	azureVMImageListURL   = "services/vmimages"
+	azureVMImageURL       = "services/vmimages/%s"
+	deleteAzureVMImageURL = "services/vmimages/%s?comp=media"
+
+	OSStateGeneralized = "Generalized"
+	OSStateSpecialized = "Specialized"
+
+	invalidVMImageError = "Can not find VM image %s in specified subscription, please specify another VM image name."
+	invalidOSStateError = "Invalid OS state: %s. Valid values are 'Generalized' and 'Specialized'"
+)
+
+func GetVMImageList() (*VMImageList, error) {
+	vmImageList := new(VMImageList)
+
+	response, err := azure.SendAzureGetRequest(azureVMImageListURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, vmImageList)
+	if err != nil {
+		return nil, err
+	}
+
+	return vmImageList, nil
+}
+
+// GetVMImage returns the VM image with the given name or label. The service
+// has no single VM image resource, so the list is searched.
+func GetVMImage(vmImageName string) (*VMImage, error) {
+	if len(vmImageName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "vmImageName")
+	}
+
+	vmImageList, err := GetVMImageList()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vmImage := range vmImageList.VMImages {
+		if vmImage.Name != vmImageName && vmImage.Label != vmImageName {
+			continue
+		}
+
+		return &vmImage, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf(invalidVMImageError, vmImageName))
+}
+
+// CreateVMImage registers a VM image from an OS disk VHD and optional data disk VHDs.
+func CreateVMImage(vmImageName, label, description string, osDisk OSDiskConfiguration, dataDisks []DataDiskConfiguration) (*VMImage, error) {
+	if len(vmImageName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "vmImageName")
+	}
+	if len(label) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "label")
+	}
+	if len(osDisk.MediaLink) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "osDisk.MediaLink")
+	}
+	if osDisk.OS != osLinux && osDisk.OS != osWindows {
+		return nil, errors.New(invalidOSError)
+	}
+	if osDisk.OSState != OSStateGeneralized && osDisk.OSState != OSStateSpecialized {
+		return nil, fmt.Errorf(invalidOSStateError, osDisk.OSState)
+	}
+
+	for _, dataDisk := range dataDisks {
+		if len(dataDisk.MediaLink) == 0 {
+			return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "dataDisk.MediaLink")
+		}
+	}
+
+	vmImageDeployment := createVMImageDeploymentConfig(vmImageName, label, description, osDisk, dataDisks)
+	vmImageDeploymentBytes, err := xml.Marshal(vmImageDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	requestId, err := azure.SendAzurePostRequest(azureVMImageListURL, vmImageDeploymentBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetVMImage(vmImageName)
+}
+
+// UpdateVMImage changes the label, description and disk host caching of a VM
+// image. Disks are matched by name; nil osDisk keeps the OS disk settings.
+func UpdateVMImage(vmImageName, label, description string, osDisk *OSDiskConfiguration, dataDisks []DataDiskConfiguration) error {
+	if len(vmImageName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "vmImageName")
+	}
+	if len(label) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "label")
+	}
+
+	vmImageUpdate := createVMImageUpdateConfig(label, description, osDisk, dataDisks)
+	vmImageUpdateBytes, err := xml.Marshal(vmImageUpdate)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureVMImageURL, vmImageName)
+	requestId, err := azure.SendAzurePutRequest(requestURL, vmImageUpdateBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func DeleteVMImage(vmImageName string, deleteVHD bool) error {
+	if len(vmImageName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "vmImageName")
+	}
+
+	requestURL := fmt.Sprintf(azureVMImageURL, vmImageName)
+	if deleteVHD {
+		requestURL = fmt.Sprintf(deleteAzureVMImageURL, vmImageName)
+	}
+
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func createVMImageDeploymentConfig(vmImageName, label, description string, osDisk OSDiskConfiguration, dataDisks []DataDiskConfiguration) VMImageDeployment {
+	vmImageDeployment := VMImageDeployment{}
+	vmImageDeployment.Xmlns = azureXmlns
+	vmImageDeployment.Name = vmImageName
+	vmImageDeployment.Label = label
+	vmImageDeployment.Description = description
+	vmImageDeployment.OSDiskConfiguration = osDisk
+	vmImageDeployment.DataDiskConfigurations = dataDisks
+
+	return vmImageDeployment
+}
+
+func createVMImageUpdateConfig(label, description string, osDisk *OSDiskConfiguration, dataDisks []DataDiskConfiguration) VMImageUpdate {
+	vmImageUpdate := VMImageUpdate{}
+	vmImageUpdate.Xmlns = azureXmlns
+	vmImageUpdate.Label = label
+	vmImageUpdate.Description = description
+	vmImageUpdate.OSDiskConfiguration = osDisk
+	vmImageUpdate.DataDiskConfigurations = dataDisks
+
+	return vmImageUpdate
+}
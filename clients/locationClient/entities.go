@@ -11,7 +11,14 @@ type LocationList struct {
 }
 
 type Location struct {
-	Name              string
-	DisplayName       string
-	AvailableServices []string `xml:"AvailableServices>AvailableService"`
+	Name                string
+	DisplayName         string
+	AvailableServices   []string `xml:"AvailableServices>AvailableService"`
+	ComputeCapabilities ComputeCapabilities
+	StorageAccountTypes []string `xml:"StorageCapabilities>StorageAccountTypes>StorageAccountType"`
+}
+
+type ComputeCapabilities struct {
+	WebWorkerRoleSizes       []string `xml:"WebWorkerRoleSizes>RoleSize"`
+	VirtualMachinesRoleSizes []string `xml:"VirtualMachinesRoleSizes>RoleSize"`
 }
@@ -0,0 +1,123 @@
+package locationClient
+
+import (
+	"errors"
+	"fmt"
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"strings"
+)
+
+const (
+	ServiceCompute          = "Compute"
+	ServiceStorage          = "Storage"
+	ServicePersistentVMRole = "PersistentVMRole"
+	ServiceHighMemory       = "HighMemory"
+
+	locationNotFoundError      = "Location %s was not found"
+	roleSizeNotInLocationError = "Role size %s is not available for virtual machines in %s. Available role sizes: %s"
+	serviceNotInLocationError  = "Service %s is not available in %s"
+)
+
+func GetLocation(location string) (*Location, error) {
+	if len(location) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "location")
+	}
+
+	locations, err := getLocationList(true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, existingLocation := range locations.Locations {
+		if existingLocation.Name == location {
+			return &existingLocation, nil
+		}
+	}
+
+	return nil, errors.New(fmt.Sprintf(locationNotFoundError, location))
+}
+
+// FindLocations returns the locations offering service and, when roleSize
+// is not empty, offering roleSize for virtual machines.
+func FindLocations(service, roleSize string) ([]Location, error) {
+	if len(service) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "service")
+	}
+
+	locations, err := getLocationList(true)
+	if err != nil {
+		return nil, err
+	}
+
+	matchingLocations := []Location{}
+	for _, location := range locations.Locations {
+		if !containsString(location.AvailableServices, service) {
+			continue
+		}
+		if len(roleSize) > 0 && !containsString(location.ComputeCapabilities.VirtualMachinesRoleSizes, roleSize) {
+			continue
+		}
+
+		matchingLocations = append(matchingLocations, location)
+	}
+
+	return matchingLocations, nil
+}
+
+func GetVMRoleSizes(location string) ([]string, error) {
+	existingLocation, err := GetLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return existingLocation.ComputeCapabilities.VirtualMachinesRoleSizes, nil
+}
+
+func GetWebWorkerRoleSizes(location string) ([]string, error) {
+	existingLocation, err := GetLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return existingLocation.ComputeCapabilities.WebWorkerRoleSizes, nil
+}
+
+// ResolveVMRoleSize checks that virtual machines of roleSize can be created in
+// location.
+func ResolveVMRoleSize(location, roleSize string) error {
+	if len(roleSize) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleSize")
+	}
+
+	existingLocation, err := GetLocation(location)
+	if err != nil {
+		return err
+	}
+
+	return resolveVMRoleSize(existingLocation, roleSize)
+}
+
+// resolveVMRoleSize accepts any role size when the location does not list its
+// virtual machine role sizes.
+func resolveVMRoleSize(location *Location, roleSize string) error {
+	if len(location.AvailableServices) > 0 && !containsString(location.AvailableServices, ServicePersistentVMRole) {
+		return errors.New(fmt.Sprintf(serviceNotInLocationError, ServicePersistentVMRole, location.Name))
+	}
+
+	roleSizes := location.ComputeCapabilities.VirtualMachinesRoleSizes
+	if len(roleSizes) == 0 || containsString(roleSizes, roleSize) {
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf(roleSizeNotInLocationError, roleSize, location.Name, strings.Join(roleSizes, ", ")))
+}
+
+func containsString(values []string, value string) bool {
+	for _, existingValue := range values {
+		if existingValue == value {
+			return true
+		}
+	}
+
+	return false
+}
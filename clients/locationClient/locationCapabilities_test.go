@@ -0,0 +1,128 @@
+package locationClient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"testing"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const testLocationListResponse = `<Locations xmlns="http://schemas.microsoft.com/windowsazure" xmlns:i="http://www.w3.org/2001/XMLSchema-instance">
+  <Location>
+    <Name>West US</Name>
+    <DisplayName>West US</DisplayName>
+    <AvailableServices>
+      <AvailableService>Compute</AvailableService>
+      <AvailableService>Storage</AvailableService>
+      <AvailableService>PersistentVMRole</AvailableService>
+      <AvailableService>HighMemory</AvailableService>
+    </AvailableServices>
+    <StorageCapabilities>
+      <StorageAccountTypes>
+        <StorageAccountType>Standard_LRS</StorageAccountType>
+        <StorageAccountType>Standard_GRS</StorageAccountType>
+      </StorageAccountTypes>
+    </StorageCapabilities>
+    <ComputeCapabilities>
+      <WebWorkerRoleSizes>
+        <RoleSize>Small</RoleSize>
+        <RoleSize>Medium</RoleSize>
+      </WebWorkerRoleSizes>
+      <VirtualMachinesRoleSizes>
+        <RoleSize>Basic_A1</RoleSize>
+        <RoleSize>Standard_D1</RoleSize>
+      </VirtualMachinesRoleSizes>
+    </ComputeCapabilities>
+  </Location>
+  <Location>
+    <Name>Brazil South</Name>
+    <DisplayName>Brazil South</DisplayName>
+    <AvailableServices>
+      <AvailableService>Compute</AvailableService>
+      <AvailableService>Storage</AvailableService>
+    </AvailableServices>
+  </Location>
+</Locations>`
+
+func Test_LocationListDecoding(t *testing.T) {
+	locationList := LocationList{}
+	err := xml.Unmarshal([]byte(testLocationListResponse), &locationList)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []Location{
+		{
+			Name:              "West US",
+			DisplayName:       "West US",
+			AvailableServices: []string{ServiceCompute, ServiceStorage, ServicePersistentVMRole, ServiceHighMemory},
+			ComputeCapabilities: ComputeCapabilities{
+				WebWorkerRoleSizes:       []string{"Small", "Medium"},
+				VirtualMachinesRoleSizes: []string{"Basic_A1", "Standard_D1"},
+			},
+			StorageAccountTypes: []string{"Standard_LRS", "Standard_GRS"},
+		},
+		{
+			Name:              "Brazil South",
+			DisplayName:       "Brazil South",
+			AvailableServices: []string{ServiceCompute, ServiceStorage},
+		},
+	}
+
+	if !reflect.DeepEqual(locationList.Locations, expected) {
+		t.Errorf("Expected: %+v, got: %+v", expected, locationList.Locations)
+	}
+}
+
+func Test_resolveVMRoleSize(t *testing.T) {
+	westUS := &Location{
+		Name:                "West US",
+		AvailableServices:   []string{ServiceCompute, ServicePersistentVMRole},
+		ComputeCapabilities: ComputeCapabilities{VirtualMachinesRoleSizes: []string{"Basic_A1", "Standard_D1"}},
+	}
+	withoutRoleSizes := &Location{
+		Name:              "East US",
+		AvailableServices: []string{ServiceCompute, ServicePersistentVMRole},
+	}
+	withoutServices := &Location{Name: "North Europe"}
+	withoutVMs := &Location{
+		Name:                "Brazil South",
+		AvailableServices:   []string{ServiceCompute, ServiceStorage},
+		ComputeCapabilities: ComputeCapabilities{VirtualMachinesRoleSizes: []string{"Basic_A1"}},
+	}
+
+	testCases := []struct {
+		location *Location
+		roleSize string
+		expected string
+	}{
+		{westUS, "Standard_D1", ""},
+		{westUS, "Standard_G5", "Role size Standard_G5 is not available for virtual machines in West US. Available role sizes: Basic_A1, Standard_D1"},
+		{withoutRoleSizes, "Standard_G5", ""},
+		{withoutServices, "Standard_G5", ""},
+		{withoutVMs, "Basic_A1", "Service PersistentVMRole is not available in Brazil South"},
+	}
+
+	for _, testCase := range testCases {
+		err := resolveVMRoleSize(testCase.location, testCase.roleSize)
+		if len(testCase.expected) == 0 {
+			if err != nil {
+				t.Errorf("resolveVMRoleSize(%s, %s): unexpected error: %s", testCase.location.Name, testCase.roleSize, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != testCase.expected {
+			t.Errorf("Expected: %s, got: %v", testCase.expected, err)
+		}
+	}
+}
+
+func Test_ResolveVMRoleSizeWithoutRoleSize(t *testing.T) {
+	err := ResolveVMRoleSize("West US", "")
+	expected := fmt.Sprintf(azure.ParamNotSpecifiedError, "roleSize")
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, err)
+	}
+}
@@ -19,7 +19,7 @@ func ResolveLocation(location string) error {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "location")
 	}
 
-	locations, err := GetLocationList()
+	locations, err := getLocationList(true)
 	if err != nil {
 		return err
 	}
@@ -41,9 +41,13 @@ func ResolveLocation(location string) error {
 }
 
 func GetLocationList() (LocationList, error) {
+	return getLocationList(false)
+}
+
+func getLocationList(cached bool) (LocationList, error) {
 	locationList := LocationList{}
 
-	response, err := azure.SendAzureGetRequest(azureLocationListURL)
+	response, err := azure.SendAzureGetRequestWithCache(azureLocationListURL, cached)
 	if err != nil {
 		return locationList, err
 	}
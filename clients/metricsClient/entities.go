@@ -0,0 +1,50 @@
+package metricsClient
+
+import (
+	"time"
+)
+
+type MetricDefinitionList struct {
+	Value []MetricDefinition
+}
+
+type MetricDefinition struct {
+	Name                 string
+	Namespace            string
+	DisplayName          string
+	Unit                 string
+	PrimaryAggregation   string
+	MetricAvailabilities []MetricAvailability
+	IsDefault            bool
+}
+
+type MetricAvailability struct {
+	TimeGrain string
+	Retention string
+}
+
+type MetricValueSetList struct {
+	Value []MetricValueSet
+}
+
+type MetricValueSet struct {
+	Name               string
+	Namespace          string
+	DisplayName        string
+	Unit               string
+	PrimaryAggregation string
+	TimeGrain          string
+	StartTime          time.Time
+	EndTime            time.Time
+	MetricValues       []MetricPoint
+}
+
+type MetricPoint struct {
+	Timestamp  time.Time
+	Average    float64
+	Minimum    float64
+	Maximum    float64
+	Total      float64
+	Count      int64
+	Annotation string
+}
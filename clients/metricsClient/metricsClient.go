@@ -0,0 +1,209 @@
+package metricsClient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	azureMetricDefinitionsURL = "services/monitoring/metricdefinitions/query?resourceId=%s"
+	azureMetricValuesURL      = "services/monitoring/metricvalues/query?resourceId=%s&names=%s&timeGrain=%s&startTime=%s&endTime=%s"
+	azureDeploymentResourceId = "/hostedservices/%s/deployments/%s"
+	azureRoleResourceId       = "/hostedservices/%s/deployments/%s/roles/%s"
+
+	monitoringVersionHeaderValue = "2013-10-01"
+	monitoringTimeFormat         = "2006-01-02T15:04:05Z"
+
+	invalidTimeRangeError   = "Invalid time range: start time %s is not before end time %s"
+	metricNotFoundError     = "Metric %s was not returned"
+	noMetricPointsError     = "Metric %s has no values"
+	invalidAggregationError = "Invalid aggregation: %s. Available aggregations: Average, Minimum, Maximum, Total"
+)
+
+const (
+	MetricPercentageCPU = "Percentage CPU"
+	MetricNetworkIn     = "Network In"
+	MetricNetworkOut    = "Network Out"
+	MetricDiskRead      = "Disk Read Bytes/sec"
+	MetricDiskWrite     = "Disk Write Bytes/sec"
+
+	TimeGrain5Minutes = "PT5M"
+	TimeGrain1Hour    = "PT1H"
+	TimeGrain12Hours  = "PT12H"
+
+	AggregationAverage = "Average"
+	AggregationMinimum = "Minimum"
+	AggregationMaximum = "Maximum"
+	AggregationTotal   = "Total"
+)
+
+//Region public methods starts
+
+func GetMetricDefinitions(resourceId string) (*MetricDefinitionList, error) {
+	if len(resourceId) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "resourceId")
+	}
+
+	definitions := new(MetricDefinitionList)
+
+	requestURL := fmt.Sprintf(azureMetricDefinitionsURL, url.QueryEscape(resourceId))
+	response, err := sendMonitoringGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(response, definitions)
+	if err != nil {
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+func GetMetricValues(resourceId string, names []string, timeGrain string, startTime, endTime time.Time) (*MetricValueSetList, error) {
+	if len(resourceId) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "resourceId")
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "names")
+	}
+	if len(timeGrain) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "timeGrain")
+	}
+	if !startTime.Before(endTime) {
+		return nil, errors.New(fmt.Sprintf(invalidTimeRangeError, startTime, endTime))
+	}
+
+	values := new(MetricValueSetList)
+
+	requestURL := fmt.Sprintf(azureMetricValuesURL,
+		url.QueryEscape(resourceId),
+		url.QueryEscape(strings.Join(names, ",")),
+		url.QueryEscape(timeGrain),
+		url.QueryEscape(startTime.UTC().Format(monitoringTimeFormat)),
+		url.QueryEscape(endTime.UTC().Format(monitoringTimeFormat)))
+	response, err := sendMonitoringGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(response, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func GetRoleMetricValues(serviceName, deploymentName, roleName string, names []string, timeGrain string, startTime, endTime time.Time) (*MetricValueSetList, error) {
+	if len(serviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "serviceName")
+	}
+	if len(deploymentName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(roleName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	}
+
+	return GetMetricValues(GetRoleResourceId(serviceName, deploymentName, roleName), names, timeGrain, startTime, endTime)
+}
+
+func GetDeploymentMetricValues(serviceName, deploymentName string, names []string, timeGrain string, startTime, endTime time.Time) (*MetricValueSetList, error) {
+	if len(serviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "serviceName")
+	}
+	if len(deploymentName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+
+	return GetMetricValues(GetDeploymentResourceId(serviceName, deploymentName), names, timeGrain, startTime, endTime)
+}
+
+func GetRoleResourceId(serviceName, deploymentName, roleName string) string {
+	return fmt.Sprintf(azureRoleResourceId, serviceName, deploymentName, roleName)
+}
+
+func GetDeploymentResourceId(serviceName, deploymentName string) string {
+	return fmt.Sprintf(azureDeploymentResourceId, serviceName, deploymentName)
+}
+
+// FindMetric returns the value set with the given metric name.
+func (list *MetricValueSetList) FindMetric(name string) (*MetricValueSet, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	for i := range list.Value {
+		if list.Value[i].Name == name {
+			return &list.Value[i], nil
+		}
+	}
+
+	return nil, errors.New(fmt.Sprintf(metricNotFoundError, name))
+}
+
+// Aggregate combines the points of the set into a single value. Averages are
+// weighted by the number of samples behind each point, totals are summed.
+func (set *MetricValueSet) Aggregate(aggregation string) (float64, error) {
+	if len(set.MetricValues) == 0 {
+		return 0, errors.New(fmt.Sprintf(noMetricPointsError, set.Name))
+	}
+
+	result := 0.0
+	switch aggregation {
+	case AggregationAverage:
+		var count int64
+		for _, point := range set.MetricValues {
+			weight := point.Count
+			if weight == 0 {
+				weight = 1
+			}
+			result += point.Average * float64(weight)
+			count += weight
+		}
+		result = result / float64(count)
+	case AggregationMinimum:
+		result = set.MetricValues[0].Minimum
+		for _, point := range set.MetricValues[1:] {
+			if point.Minimum < result {
+				result = point.Minimum
+			}
+		}
+	case AggregationMaximum:
+		result = set.MetricValues[0].Maximum
+		for _, point := range set.MetricValues[1:] {
+			if point.Maximum > result {
+				result = point.Maximum
+			}
+		}
+	case AggregationTotal:
+		for _, point := range set.MetricValues {
+			result += point.Total
+		}
+	default:
+		return 0, errors.New(fmt.Sprintf(invalidAggregationError, aggregation))
+	}
+
+	return result, nil
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func sendMonitoringGetRequest(requestURL string) ([]byte, error) {
+	headers := map[string]string{
+		"x-ms-version": monitoringVersionHeaderValue,
+		"Accept":       "application/json",
+	}
+
+	return azure.SendAzureGetRequestWithHeaders(requestURL, headers)
+}
+
+//Region private methods ends
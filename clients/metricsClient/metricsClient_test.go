@@ -0,0 +1,196 @@
+package metricsClient
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testMetricDefinitionsResponse = `{
+  "Value": [
+    {
+      "Name": "Percentage CPU",
+      "Namespace": "",
+      "DisplayName": "Percentage CPU",
+      "Unit": "Percent",
+      "PrimaryAggregation": "Average",
+      "MetricAvailabilities": [
+        {"TimeGrain": "PT5M", "Retention": "P2D"},
+        {"TimeGrain": "PT1H", "Retention": "P90D"}
+      ],
+      "IsDefault": true
+    }
+  ]
+}`
+
+const testMetricValuesResponse = `{
+  "Value": [
+    {
+      "Name": "Network Out",
+      "Namespace": "",
+      "DisplayName": "Network Out",
+      "Unit": "Bytes",
+      "PrimaryAggregation": "Total",
+      "TimeGrain": "PT5M",
+      "StartTime": "2015-03-02T10:00:00Z",
+      "EndTime": "2015-03-02T10:10:00+01:00",
+      "MetricValues": [
+        {"Timestamp": "2015-03-02T10:00:00Z", "Average": 512.5, "Minimum": 12, "Maximum": 2048, "Total": 5125, "Count": 10},
+        {"Timestamp": "2015-03-02T10:05:00.5Z", "Total": 300, "Annotation": "partial"}
+      ]
+    }
+  ]
+}`
+
+func Test_Aggregate(t *testing.T) {
+	set := MetricValueSet{
+		Name: MetricPercentageCPU,
+		MetricValues: []MetricPoint{
+			{Average: 10, Minimum: 5, Maximum: 20, Total: 30, Count: 3},
+			{Average: 40, Minimum: 2, Maximum: 90, Total: 40, Count: 1},
+		},
+	}
+
+	testCases := []struct {
+		aggregation string
+		expected    float64
+	}{
+		{AggregationAverage, 17.5},
+		{AggregationMinimum, 2},
+		{AggregationMaximum, 90},
+		{AggregationTotal, 70},
+	}
+
+	for _, testCase := range testCases {
+		output, err := set.Aggregate(testCase.aggregation)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", testCase.aggregation, err)
+			continue
+		}
+		if output != testCase.expected {
+			t.Errorf("%s: expected %v, got %v", testCase.aggregation, testCase.expected, output)
+		}
+	}
+}
+
+func Test_AggregateWithoutCounts(t *testing.T) {
+	set := MetricValueSet{
+		Name: MetricPercentageCPU,
+		MetricValues: []MetricPoint{
+			{Average: 10},
+			{Average: 30},
+		},
+	}
+
+	output, err := set.Aggregate(AggregationAverage)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if output != 20 {
+		t.Errorf("Expected: 20, got: %v", output)
+	}
+}
+
+func Test_AggregateErrors(t *testing.T) {
+	testCases := []struct {
+		set         MetricValueSet
+		aggregation string
+		expected    string
+	}{
+		{MetricValueSet{Name: MetricNetworkIn}, AggregationAverage, "Metric Network In has no values"},
+		{MetricValueSet{Name: MetricNetworkIn, MetricValues: []MetricPoint{{Average: 1}}}, "Median", "Invalid aggregation: Median. Available aggregations: Average, Minimum, Maximum, Total"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := testCase.set.Aggregate(testCase.aggregation)
+		if err == nil || err.Error() != testCase.expected {
+			t.Errorf("Expected: %s, got: %v", testCase.expected, err)
+		}
+	}
+}
+
+func Test_FindMetric(t *testing.T) {
+	list := MetricValueSetList{Value: []MetricValueSet{{Name: MetricNetworkIn}, {Name: MetricPercentageCPU}}}
+
+	set, err := list.FindMetric(MetricPercentageCPU)
+	if err != nil || set.Name != MetricPercentageCPU {
+		t.Errorf("Expected: %s, got: %v %v", MetricPercentageCPU, set, err)
+	}
+
+	_, err = list.FindMetric(MetricDiskRead)
+	if err == nil {
+		t.Errorf("Expected: error for %s", MetricDiskRead)
+	}
+}
+
+func Test_MetricDefinitionListDecoding(t *testing.T) {
+	definitions := MetricDefinitionList{}
+	err := json.Unmarshal([]byte(testMetricDefinitionsResponse), &definitions)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := MetricDefinitionList{Value: []MetricDefinition{{
+		Name:               MetricPercentageCPU,
+		DisplayName:        "Percentage CPU",
+		Unit:               "Percent",
+		PrimaryAggregation: AggregationAverage,
+		MetricAvailabilities: []MetricAvailability{
+			{TimeGrain: "PT5M", Retention: "P2D"},
+			{TimeGrain: "PT1H", Retention: "P90D"},
+		},
+		IsDefault: true,
+	}}}
+
+	if !reflect.DeepEqual(definitions, expected) {
+		t.Errorf("Expected: %+v, got: %+v", expected, definitions)
+	}
+}
+
+func Test_MetricValueSetListDecoding(t *testing.T) {
+	values := MetricValueSetList{}
+	err := json.Unmarshal([]byte(testMetricValuesResponse), &values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(values.Value) != 1 || len(values.Value[0].MetricValues) != 2 {
+		t.Fatalf("Expected: 1 set of 2 points, got: %+v", values)
+	}
+
+	set := values.Value[0]
+	if set.Name != MetricNetworkOut || set.Unit != "Bytes" || set.PrimaryAggregation != AggregationTotal || set.TimeGrain != "PT5M" {
+		t.Errorf("Expected: Network Out in Bytes, Total over PT5M, got: %+v", set)
+	}
+
+	expectedStart := time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC)
+	if !set.StartTime.Equal(expectedStart) {
+		t.Errorf("Expected: %s, got: %s", expectedStart, set.StartTime)
+	}
+	expectedEnd := time.Date(2015, 3, 2, 9, 10, 0, 0, time.UTC)
+	if !set.EndTime.Equal(expectedEnd) {
+		t.Errorf("Expected: %s, got: %s", expectedEnd, set.EndTime)
+	}
+
+	expectedPoints := []MetricPoint{
+		{Timestamp: time.Date(2015, 3, 2, 10, 0, 0, 0, time.UTC), Average: 512.5, Minimum: 12, Maximum: 2048, Total: 5125, Count: 10},
+		{Timestamp: time.Date(2015, 3, 2, 10, 5, 0, 500000000, time.UTC), Total: 300, Annotation: "partial"},
+	}
+	for i, point := range set.MetricValues {
+		expectedPoint := expectedPoints[i]
+		if !point.Timestamp.Equal(expectedPoint.Timestamp) {
+			t.Errorf("Expected: %s, got: %s", expectedPoint.Timestamp, point.Timestamp)
+		}
+
+		point.Timestamp = expectedPoint.Timestamp
+		if point != expectedPoint {
+			t.Errorf("Expected: %+v, got: %+v", expectedPoint, point)
+		}
+	}
+
+	total, err := set.Aggregate(AggregationTotal)
+	if err != nil || total != 5425 {
+		t.Errorf("Expected: 5425, got: %v %v", total, err)
+	}
+}
@@ -0,0 +1,31 @@
+package reservedIPClient
+
+import (
+	"encoding/xml"
+)
+
+type ReservedIPList struct {
+	XMLName     xml.Name     `xml:"ReservedIPs"`
+	Xmlns       string       `xml:"xmlns,attr"`
+	ReservedIPs []ReservedIP `xml:"ReservedIP"`
+}
+
+type ReservedIP struct {
+	Name           string
+	Address        string
+	Id             string
+	Label          string
+	State          string
+	InUse          bool
+	ServiceName    string
+	DeploymentName string
+	Location       string
+}
+
+type ReservedIPDeployment struct {
+	XMLName  xml.Name `xml:"ReservedIP"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Name     string
+	Label    string `xml:",omitempty"`
+	Location string
+}
@@ -0,0 +1,128 @@
+package reservedIPClient
+
+import (
+	"encoding/xml"
+	"fmt"
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/locationClient"
+)
+
+const (
+	azureXmlns             = "http://schemas.microsoft.com/windowsazure"
+	azureReservedIPListURL = "services/networking/reservedips"
+	azureReservedIPURL     = "services/networking/reservedips/%s"
+)
+
+func GetReservedIPList() (*ReservedIPList, error) {
+	reservedIPList := new(ReservedIPList)
+
+	response, err := azure.SendAzureGetRequest(azureReservedIPListURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, reservedIPList)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservedIPList, nil
+}
+
+func GetReservedIP(name string) (*ReservedIP, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	reservedIP := new(ReservedIP)
+
+	requestURL := fmt.Sprintf(azureReservedIPURL, name)
+	response, err := azure.SendAzureGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, reservedIP)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservedIP, nil
+}
+
+func CreateReservedIP(name, label, location string) (*ReservedIP, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+	if len(location) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "location")
+	}
+
+	err := locationClient.ResolveLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	reservedIPDeployment := createReservedIPDeploymentConf(name, label, location)
+	deploymentBytes, err := xml.Marshal(reservedIPDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	requestId, err := azure.SendAzurePostRequest(azureReservedIPListURL, deploymentBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetReservedIP(name)
+}
+
+func DeleteReservedIP(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	requestURL := fmt.Sprintf(azureReservedIPURL, name)
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+// GetReservedIPsInUse returns the reserved IPs currently bound to a deployment,
+// keyed by "<service name>/<deployment name>".
+func GetReservedIPsInUse() (map[string]ReservedIP, error) {
+	reservedIPList, err := GetReservedIPList()
+	if err != nil {
+		return nil, err
+	}
+
+	reservedIPs := make(map[string]ReservedIP)
+	for _, reservedIP := range reservedIPList.ReservedIPs {
+		if !reservedIP.InUse {
+			continue
+		}
+
+		reservedIPs[reservedIP.ServiceName+"/"+reservedIP.DeploymentName] = reservedIP
+	}
+
+	return reservedIPs, nil
+}
+
+func createReservedIPDeploymentConf(name, label, location string) ReservedIPDeployment {
+	reservedIPDeployment := ReservedIPDeployment{}
+
+	reservedIPDeployment.Name = name
+	reservedIPDeployment.Label = label
+	reservedIPDeployment.Location = location
+	reservedIPDeployment.Xmlns = azureXmlns
+
+	return reservedIPDeployment
+}
@@ -14,29 +14,66 @@ type StorageService struct {
 	Url                      string
 	ServiceName              string
 	StorageServiceProperties StorageServiceProperties
+	ExtendedProperties       []ExtendedProperty `xml:"ExtendedProperties>ExtendedProperty"`
 }
 
 type StorageServiceProperties struct {
 	Description           string
+	AffinityGroup         string
 	Location              string
 	Label                 string
 	Status                string
 	Endpoints             []string `xml:"Endpoints>Endpoint"`
 	GeoReplicationEnabled string
 	GeoPrimaryRegion      string
+	StatusOfPrimary       string
+	LastGeoFailoverTime   string
+	GeoSecondaryRegion    string
+	StatusOfSecondary     string
+	CreationTime          string
+	SecondaryEndpoints    []string `xml:"SecondaryEndpoints>SecondaryEndpoint"`
+	AccountType           string
+}
+
+type StorageServiceKeys struct {
+	Url         string
+	ServiceName string
+	Primary     string `xml:"StorageServiceKeys>Primary"`
+	Secondary   string `xml:"StorageServiceKeys>Secondary"`
+}
+
+type RegenerateKeys struct {
+	XMLName xml.Name `xml:"RegenerateKeys"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	KeyType string
 }
 
 type StorageServiceDeployment struct {
-	XMLName               xml.Name `xml:"CreateStorageServiceInput"`
-	Xmlns                 string   `xml:"xmlns,attr"`
-	ServiceName           string
-	Description           string
-	Label                 string
-	AffinityGroup         string `xml:",omitempty"`
-	Location              string `xml:",omitempty"`
-	GeoReplicationEnabled bool
-	ExtendedProperties    ExtendedPropertyList
-	SecondaryReadEnabled  bool
+	XMLName            xml.Name `xml:"CreateStorageServiceInput"`
+	Xmlns              string   `xml:"xmlns,attr"`
+	ServiceName        string
+	Description        string
+	Label              string
+	AffinityGroup      string `xml:",omitempty"`
+	Location           string `xml:",omitempty"`
+	ExtendedProperties ExtendedPropertyList
+	AccountType        string
+}
+
+type StorageServiceUpdate struct {
+	XMLName            xml.Name `xml:"UpdateStorageServiceInput"`
+	Xmlns              string   `xml:"xmlns,attr"`
+	Description        string   `xml:",omitempty"`
+	Label              string   `xml:",omitempty"`
+	AccountType        string   `xml:",omitempty"`
+	ExtendedProperties *ExtendedPropertyList
+}
+
+type AvailabilityResponse struct {
+	XMLName xml.Name `xml:"AvailabilityResponse"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Result  bool
+	Reason  string
 }
 
 type ExtendedPropertyList struct {
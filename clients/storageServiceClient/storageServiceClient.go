@@ -6,21 +6,44 @@ import (
 	"errors"
 	"fmt"
 	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/storage"
+	"io/ioutil"
+	"net/url"
 	"strings"
 )
 
 const (
-	azureXmlns                 = "http://schemas.microsoft.com/windowsazure"
-	azureStorageServiceListURL = "services/storageservices"
-	azureStorageServiceURL     = "services/storageservices/%s"
+	azureXmlns                  = "http://schemas.microsoft.com/windowsazure"
+	azureStorageServiceListURL  = "services/storageservices"
+	azureStorageServiceURL      = "services/storageservices/%s"
+	azureStorageServiceKeysURL  = "services/storageservices/%s/keys"
+	regenerateStorageKeysURL    = "services/storageservices/%s/keys?action=regenerate"
+	azureStorageAvailabilityURL = "services/storageservices/operations/isavailable/%s"
+
+	// AccountType replaced GeoReplicationEnabled in this API version
+	accountTypeVersionHeader      = "x-ms-version"
+	accountTypeVersionHeaderValue = "2014-06-01"
+
+	AccountTypeStandardLRS   = "Standard_LRS"
+	AccountTypeStandardZRS   = "Standard_ZRS"
+	AccountTypeStandardGRS   = "Standard_GRS"
+	AccountTypeStandardRAGRS = "Standard_RAGRS"
+
+	KeyTypePrimary   = "Primary"
+	KeyTypeSecondary = "Secondary"
 
 	blobEndpointNotFoundError = "Blob endpoint was not found in storage serice %s"
+	invalidKeyTypeError       = "Invalid key type: %s. Valid values are 'Primary' and 'Secondary'"
+	invalidBlobEndpointError  = "Invalid blob endpoint %s in storage service %s"
+	invalidAccountTypeError   = "Invalid account type: %s. Valid values are 'Standard_LRS', 'Standard_ZRS', 'Standard_GRS' and 'Standard_RAGRS'"
+	invalidPlacementError     = "You must specify either a location or an affinity group"
+	emptyStorageUpdateError   = "You should specify a label, a description, an account type or extended properties to update storage service %s"
 )
 
 func GetStorageServiceList() (*StorageServiceList, error) {
 	storageServiceList := new(StorageServiceList)
 
-	response, err := azure.SendAzureGetRequest(azureStorageServiceListURL)
+	response, err := azure.SendAzureGetRequestWithHeaders(azureStorageServiceListURL, getAccountTypeHeaders())
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +63,7 @@ func GetStorageServiceByName(serviceName string) (*StorageService, error) {
 
 	storageService := new(StorageService)
 	requestURL := fmt.Sprintf(azureStorageServiceURL, serviceName)
-	response, err := azure.SendAzureGetRequest(requestURL)
+	response, err := azure.SendAzureGetRequestWithHeaders(requestURL, getAccountTypeHeaders())
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +76,105 @@ func GetStorageServiceByName(serviceName string) (*StorageService, error) {
 	return storageService, nil
 }
 
+func GetStorageServiceKeys(serviceName string) (*StorageServiceKeys, error) {
+	if len(serviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "serviceName")
+	}
+
+	storageServiceKeys := new(StorageServiceKeys)
+	requestURL := fmt.Sprintf(azureStorageServiceKeysURL, serviceName)
+	response, err := azure.SendAzureGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, storageServiceKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return storageServiceKeys, nil
+}
+
+// RegenerateStorageServiceKeys replaces the primary or secondary access key
+// and returns the new keys.
+func RegenerateStorageServiceKeys(serviceName, keyType string) (*StorageServiceKeys, error) {
+	if len(serviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "serviceName")
+	}
+	if keyType != KeyTypePrimary && keyType != KeyTypeSecondary {
+		return nil, fmt.Errorf(invalidKeyTypeError, keyType)
+	}
+
+	regenerateKeys := RegenerateKeys{}
+	regenerateKeys.KeyType = keyType
+	regenerateKeys.Xmlns = azureXmlns
+
+	regenerateKeysBytes, err := xml.Marshal(regenerateKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	requestURL := fmt.Sprintf(regenerateStorageKeysURL, serviceName)
+	response, err := azure.SendAzureRequest(requestURL, "POST", regenerateKeysBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	responseContent, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	storageServiceKeys := new(StorageServiceKeys)
+	err = xml.Unmarshal(responseContent, storageServiceKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return storageServiceKeys, nil
+}
+
+// GetStorageClient returns a data plane client for the storage service,
+// authenticated with its primary key and using its own blob endpoint.
+func GetStorageClient(storageService *StorageService) (*storage.StorageClient, error) {
+	if storageService == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "storageService")
+	}
+
+	blobEndpoint, err := GetBlobEndpoint(storageService)
+	if err != nil {
+		return nil, err
+	}
+
+	endpointURL, err := url.Parse(blobEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	hostParts := strings.SplitN(endpointURL.Host, ".blob.", 2)
+	if len(hostParts) != 2 {
+		return nil, errors.New(fmt.Sprintf(invalidBlobEndpointError, blobEndpoint, storageService.ServiceName))
+	}
+
+	storageServiceKeys, err := GetStorageServiceKeys(storageService.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewClient(hostParts[0], storageServiceKeys.Primary, hostParts[1], storage.DefaultApiVersion, endpointURL.Scheme == "https")
+}
+
+func GetStorageClientByName(serviceName string) (*storage.StorageClient, error) {
+	storageService, err := GetStorageServiceByName(serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetStorageClient(storageService)
+}
+
 func GetStorageServiceByLocation(location string) (*StorageService, error) {
 	if len(location) == 0 {
 		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "location")
@@ -83,29 +205,106 @@ func CreateStorageService(name, location string) (*StorageService, error) {
 		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "location")
 	}
 
-	storageDeploymentConfig := createStorageServiceDeploymentConf(name, location)
-	deploymentBytes, err := xml.Marshal(storageDeploymentConfig)
+	return createStorageService(name, location, "", AccountTypeStandardLRS, "", nil)
+}
+
+func CreateStorageServiceInAffinityGroup(name, affinityGroup string) (*StorageService, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+	if len(affinityGroup) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "affinityGroup")
+	}
+
+	return createStorageService(name, "", affinityGroup, AccountTypeStandardLRS, "", nil)
+}
+
+// CreateStorageServiceWithAccountType creates a storage service with the given
+// replication type in either location or affinityGroup.
+func CreateStorageServiceWithAccountType(name, location, affinityGroup, accountType, description string, extendedProperties []ExtendedProperty) (*StorageService, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+	if (len(location) == 0) == (len(affinityGroup) == 0) {
+		return nil, errors.New(invalidPlacementError)
+	}
+
+	err := verifyAccountType(accountType)
 	if err != nil {
 		return nil, err
 	}
 
-	requestId, err := azure.SendAzurePostRequest(azureStorageServiceListURL, deploymentBytes)
+	return createStorageService(name, location, affinityGroup, accountType, description, extendedProperties)
+}
+
+func CheckStorageServiceNameAvailability(name string) (bool, string, error) {
+	if len(name) == 0 {
+		return false, "", fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	requestURL := fmt.Sprintf(azureStorageAvailabilityURL, name)
+	response, err := azure.SendAzureGetRequest(requestURL)
 	if err != nil {
-		return nil, err
+		return false, "", err
 	}
 
-	azure.WaitAsyncOperation(requestId)
-	storageService, err := GetStorageServiceByName(storageDeploymentConfig.ServiceName)
+	availabilityResponse := new(AvailabilityResponse)
+	err = xml.Unmarshal(response, availabilityResponse)
 	if err != nil {
-		return nil, err
+		return false, "", err
 	}
 
-	return storageService, nil
+	return availabilityResponse.Result, availabilityResponse.Reason, nil
+}
+
+// UpdateStorageService changes the label, description, replication type or
+// extended properties of a storage service. Empty values are left unchanged.
+func UpdateStorageService(name, label, description, accountType string, extendedProperties []ExtendedProperty) error {
+	if len(name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+	if len(label) == 0 && len(description) == 0 && len(accountType) == 0 && len(extendedProperties) == 0 {
+		return fmt.Errorf(emptyStorageUpdateError, name)
+	}
+	if len(accountType) > 0 {
+		err := verifyAccountType(accountType)
+		if err != nil {
+			return err
+		}
+	}
+
+	storageServiceUpdate := createStorageServiceUpdateConf(label, description, accountType, extendedProperties)
+	updateBytes, err := xml.Marshal(storageServiceUpdate)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureStorageServiceURL, name)
+	requestId, err := azure.SendAzurePutRequestWithHeaders(requestURL, getAccountTypeHeaders(), updateBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func DeleteStorageService(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	requestURL := fmt.Sprintf(azureStorageServiceURL, name)
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
 }
 
 func GetBlobEndpoint(storageService *StorageService) (string, error) {
 	for _, endpoint := range storageService.StorageServiceProperties.Endpoints {
-		if !strings.Contains(endpoint, ".blob.core") {
+		if !strings.Contains(endpoint, ".blob.") {
 			continue
 		}
 
@@ -115,14 +314,74 @@ func GetBlobEndpoint(storageService *StorageService) (string, error) {
 	return "", errors.New(fmt.Sprintf(blobEndpointNotFoundError, storageService.ServiceName))
 }
 
-func createStorageServiceDeploymentConf(name, location string) StorageServiceDeployment {
+func createStorageService(name, location, affinityGroup, accountType, description string, extendedProperties []ExtendedProperty) (*StorageService, error) {
+	storageDeploymentConfig := createStorageServiceDeploymentConf(name, location, affinityGroup, accountType, description, extendedProperties)
+	deploymentBytes, err := xml.Marshal(storageDeploymentConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	requestId, err := azure.SendAzurePostRequestWithHeaders(azureStorageServiceListURL, getAccountTypeHeaders(), deploymentBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return nil, err
+	}
+
+	storageService, err := GetStorageServiceByName(storageDeploymentConfig.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return storageService, nil
+}
+
+func getAccountTypeHeaders() map[string]string {
+	return map[string]string{
+		accountTypeVersionHeader: accountTypeVersionHeaderValue,
+	}
+}
+
+func createStorageServiceDeploymentConf(name, location, affinityGroup, accountType, description string, extendedProperties []ExtendedProperty) StorageServiceDeployment {
 	storageServiceDeployment := StorageServiceDeployment{}
 
 	storageServiceDeployment.ServiceName = name
 	label := base64.StdEncoding.EncodeToString([]byte(name))
 	storageServiceDeployment.Label = label
+	storageServiceDeployment.Description = description
 	storageServiceDeployment.Location = location
+	storageServiceDeployment.AffinityGroup = affinityGroup
+	storageServiceDeployment.AccountType = accountType
+	storageServiceDeployment.ExtendedProperties.ExtendedProperty = extendedProperties
 	storageServiceDeployment.Xmlns = azureXmlns
 
 	return storageServiceDeployment
 }
+
+func createStorageServiceUpdateConf(label, description, accountType string, extendedProperties []ExtendedProperty) StorageServiceUpdate {
+	storageServiceUpdate := StorageServiceUpdate{}
+
+	if len(label) > 0 {
+		storageServiceUpdate.Label = base64.StdEncoding.EncodeToString([]byte(label))
+	}
+	storageServiceUpdate.Description = description
+	storageServiceUpdate.AccountType = accountType
+	if len(extendedProperties) > 0 {
+		storageServiceUpdate.ExtendedProperties = &ExtendedPropertyList{ExtendedProperty: extendedProperties}
+	}
+	storageServiceUpdate.Xmlns = azureXmlns
+
+	return storageServiceUpdate
+}
+
+func verifyAccountType(accountType string) error {
+	switch accountType {
+	case AccountTypeStandardLRS, AccountTypeStandardZRS, AccountTypeStandardGRS, AccountTypeStandardRAGRS:
+		return nil
+	}
+
+	return fmt.Errorf(invalidAccountTypeError, accountType)
+}
@@ -0,0 +1,152 @@
+package vmClient
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	azureRDPFileURL = "services/hostedservices/%s/deployments/%s/roleinstances/%s/ModelFile?FileType=RDP"
+
+	ConnectionProtocolSsh = "ssh"
+	ConnectionProtocolRdp = "rdp"
+
+	sshEndpointName = "ssh"
+	rdpEndpointName = "RemoteDesktop"
+	sshLocalPort    = 22
+	rdpLocalPort    = 3389
+)
+
+//Region public methods starts
+
+// GetAzureVMConnections returns how to reach every role instance of the
+// deployment over SSH or RDP. Instances without such an endpoint are skipped.
+// The service never returns provisioning configurations, so the user name the
+// VMs were provisioned with has to be passed in; it may be empty.
+func GetAzureVMConnections(cloudserviceName, deploymentName, userName string) ([]ConnectionInfo, error) {
+	if len(cloudserviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+
+	deployment, err := GetVMDeployment(cloudserviceName, deploymentName)
+	if err != nil {
+		return nil, err
+	}
+
+	return getDeploymentConnections(deployment, cloudserviceName, userName), nil
+}
+
+// DownloadRDPFile returns the .rdp file generated by the service for a role instance.
+func DownloadRDPFile(cloudserviceName, deploymentName, instanceName string) ([]byte, error) {
+	if len(cloudserviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(instanceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "instanceName")
+	}
+
+	requestURL := fmt.Sprintf(azureRDPFileURL, cloudserviceName, deploymentName, instanceName)
+	return azure.SendAzureGetRequest(requestURL)
+}
+
+// CreateRDPFile builds an .rdp file for the connection without calling the service.
+func CreateRDPFile(connection ConnectionInfo) []byte {
+	var rdpFile bytes.Buffer
+	rdpFile.WriteString("full address:s:" + connection.Host + ":" + strconv.Itoa(connection.PublicPort) + "\r\n")
+	rdpFile.WriteString("prompt for credentials:i:1\r\n")
+	if len(connection.UserName) > 0 {
+		rdpFile.WriteString("username:s:" + connection.UserName + "\r\n")
+	}
+
+	return rdpFile.Bytes()
+}
+
+// CreateSshConfig builds an ssh_config snippet with one Host entry per SSH
+// connection, named after the role. identityFile may be empty.
+func CreateSshConfig(connections []ConnectionInfo, identityFile string) []byte {
+	var sshConfig bytes.Buffer
+	for _, connection := range connections {
+		if connection.Protocol != ConnectionProtocolSsh {
+			continue
+		}
+
+		sshConfig.WriteString("Host " + connection.RoleName + "\n")
+		sshConfig.WriteString("    HostName " + connection.Host + "\n")
+		sshConfig.WriteString("    Port " + strconv.Itoa(connection.PublicPort) + "\n")
+		if len(connection.UserName) > 0 {
+			sshConfig.WriteString("    User " + connection.UserName + "\n")
+		}
+		if len(identityFile) > 0 {
+			sshConfig.WriteString("    IdentityFile " + identityFile + "\n")
+		}
+		sshConfig.WriteString("\n")
+	}
+
+	return sshConfig.Bytes()
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func getDeploymentConnections(deployment *VMDeployment, cloudserviceName, userName string) []ConnectionInfo {
+	host := cloudserviceName + cloudServiceDomainSuffix
+	if deploymentURL, err := url.Parse(deployment.Url); err == nil && len(deploymentURL.Host) > 0 {
+		host = deploymentURL.Host
+	}
+
+	roles := make(map[string]*Role)
+	for _, role := range deployment.RoleList.Role {
+		roles[role.RoleName] = role
+	}
+
+	connections := []ConnectionInfo{}
+	for _, instance := range deployment.RoleInstanceList.RoleInstance {
+		for _, endpoint := range instance.InstanceEndpoints.InstanceEndpoint {
+			protocol := getConnectionProtocol(endpoint)
+			if len(protocol) == 0 {
+				continue
+			}
+
+			connection := ConnectionInfo{}
+			connection.RoleName = instance.RoleName
+			connection.InstanceName = instance.InstanceName
+			connection.Protocol = protocol
+			connection.Host = host
+			connection.Vip = endpoint.Vip
+			connection.PublicPort = endpoint.PublicPort
+			connection.UserName = userName
+			if role, ok := roles[instance.RoleName]; ok {
+				connection.OS = role.OSVirtualHardDisk.OS
+			}
+
+			connections = append(connections, connection)
+		}
+	}
+
+	return connections
+}
+
+func getConnectionProtocol(endpoint InstanceEndpoint) string {
+	switch {
+	case strings.EqualFold(endpoint.Name, sshEndpointName) || endpoint.LocalPort == sshLocalPort:
+		return ConnectionProtocolSsh
+	case strings.EqualFold(endpoint.Name, rdpEndpointName) || endpoint.LocalPort == rdpLocalPort:
+		return ConnectionProtocolRdp
+	}
+
+	return ""
+}
+
+//Region private methods ends
@@ -0,0 +1,123 @@
+package vmClient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newConnectionTestDeployment(deploymentURL string) *VMDeployment {
+	deployment := &VMDeployment{Url: deploymentURL}
+	deployment.RoleList.Role = []*Role{
+		{RoleName: "linux", OSVirtualHardDisk: OSVirtualHardDisk{OS: "Linux"}},
+		{RoleName: "windows", OSVirtualHardDisk: OSVirtualHardDisk{OS: "Windows"}},
+	}
+
+	linux := &RoleInstance{RoleName: "linux", InstanceName: "linux"}
+	linux.InstanceEndpoints.InstanceEndpoint = []InstanceEndpoint{
+		{Name: "SSH", Vip: "23.96.1.1", PublicPort: 22, LocalPort: 22},
+		{Name: "http", Vip: "23.96.1.1", PublicPort: 80, LocalPort: 80},
+	}
+	windows := &RoleInstance{RoleName: "windows", InstanceName: "windows"}
+	windows.InstanceEndpoints.InstanceEndpoint = []InstanceEndpoint{
+		{Name: "RemoteDesktop", Vip: "23.96.1.1", PublicPort: 53389, LocalPort: 3389},
+		{Name: "PowerShell", Vip: "23.96.1.1", PublicPort: 5986, LocalPort: 5986},
+		{Name: "admin", Vip: "23.96.1.1", PublicPort: 50022, LocalPort: 22},
+	}
+	deployment.RoleInstanceList.RoleInstance = []*RoleInstance{linux, windows}
+
+	return deployment
+}
+
+func Test_getDeploymentConnections(t *testing.T) {
+	deployment := newConnectionTestDeployment("http://bastion.cloudapp.net/")
+
+	expected := []ConnectionInfo{
+		{RoleName: "linux", InstanceName: "linux", OS: "Linux", Protocol: ConnectionProtocolSsh, Host: "bastion.cloudapp.net", Vip: "23.96.1.1", PublicPort: 22, UserName: "azureuser"},
+		{RoleName: "windows", InstanceName: "windows", OS: "Windows", Protocol: ConnectionProtocolRdp, Host: "bastion.cloudapp.net", Vip: "23.96.1.1", PublicPort: 53389, UserName: "azureuser"},
+		{RoleName: "windows", InstanceName: "windows", OS: "Windows", Protocol: ConnectionProtocolSsh, Host: "bastion.cloudapp.net", Vip: "23.96.1.1", PublicPort: 50022, UserName: "azureuser"},
+	}
+
+	connections := getDeploymentConnections(deployment, "service", "azureuser")
+	if !reflect.DeepEqual(connections, expected) {
+		t.Errorf("Expected: %+v, got: %+v", expected, connections)
+	}
+}
+
+func Test_getDeploymentConnectionsHost(t *testing.T) {
+	testCases := []struct {
+		deploymentURL string
+		expected      string
+	}{
+		{"http://bastion.cloudapp.net/", "bastion.cloudapp.net"},
+		{"", "service.cloudapp.net"},
+		{"not a url", "service.cloudapp.net"},
+	}
+
+	for _, testCase := range testCases {
+		connections := getDeploymentConnections(newConnectionTestDeployment(testCase.deploymentURL), "service", "")
+		if connections[0].Host != testCase.expected {
+			t.Errorf("Expected: %s, got: %s", testCase.expected, connections[0].Host)
+		}
+	}
+}
+
+func Test_getConnectionProtocol(t *testing.T) {
+	testCases := []struct {
+		endpoint InstanceEndpoint
+		expected string
+	}{
+		{InstanceEndpoint{Name: "ssh", LocalPort: 2222}, ConnectionProtocolSsh},
+		{InstanceEndpoint{Name: "jump", LocalPort: 22}, ConnectionProtocolSsh},
+		{InstanceEndpoint{Name: "remotedesktop", LocalPort: 13389}, ConnectionProtocolRdp},
+		{InstanceEndpoint{Name: "rdp", LocalPort: 3389}, ConnectionProtocolRdp},
+		{InstanceEndpoint{Name: "http", LocalPort: 80}, ""},
+	}
+
+	for _, testCase := range testCases {
+		if output := getConnectionProtocol(testCase.endpoint); output != testCase.expected {
+			t.Errorf("getConnectionProtocol(%+v): expected %q, got %q", testCase.endpoint, testCase.expected, output)
+		}
+	}
+}
+
+func Test_CreateRDPFile(t *testing.T) {
+	testCases := []struct {
+		connection ConnectionInfo
+		expected   string
+	}{
+		{ConnectionInfo{Host: "bastion.cloudapp.net", PublicPort: 53389, UserName: "azureuser"},
+			"full address:s:bastion.cloudapp.net:53389\r\nprompt for credentials:i:1\r\nusername:s:azureuser\r\n"},
+		{ConnectionInfo{Host: "bastion.cloudapp.net", PublicPort: 3389},
+			"full address:s:bastion.cloudapp.net:3389\r\nprompt for credentials:i:1\r\n"},
+	}
+
+	for _, testCase := range testCases {
+		if output := string(CreateRDPFile(testCase.connection)); output != testCase.expected {
+			t.Errorf("Expected: %q, got: %q", testCase.expected, output)
+		}
+	}
+}
+
+func Test_CreateSshConfig(t *testing.T) {
+	connections := []ConnectionInfo{
+		{RoleName: "web1", Protocol: ConnectionProtocolSsh, Host: "bastion.cloudapp.net", PublicPort: 22, UserName: "azureuser"},
+		{RoleName: "windows", Protocol: ConnectionProtocolRdp, Host: "bastion.cloudapp.net", PublicPort: 53389, UserName: "azureuser"},
+		{RoleName: "web2", Protocol: ConnectionProtocolSsh, Host: "bastion.cloudapp.net", PublicPort: 50022},
+	}
+
+	testCases := []struct {
+		identityFile string
+		expected     string
+	}{
+		{"~/.ssh/azure", "Host web1\n    HostName bastion.cloudapp.net\n    Port 22\n    User azureuser\n    IdentityFile ~/.ssh/azure\n\n" +
+			"Host web2\n    HostName bastion.cloudapp.net\n    Port 50022\n    IdentityFile ~/.ssh/azure\n\n"},
+		{"", "Host web1\n    HostName bastion.cloudapp.net\n    Port 22\n    User azureuser\n\n" +
+			"Host web2\n    HostName bastion.cloudapp.net\n    Port 50022\n\n"},
+	}
+
+	for _, testCase := range testCases {
+		if output := string(CreateSshConfig(connections, testCase.identityFile)); output != testCase.expected {
+			t.Errorf("Expected: %q, got: %q", testCase.expected, output)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package vmClient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"unicode/utf8"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	CloudInitShellScript = "text/x-shellscript"
+	CloudInitCloudConfig = "text/cloud-config"
+	CloudInitIncludeURL  = "text/x-include-url"
+	CloudInitBoothook    = "text/cloud-boothook"
+	CloudInitUpstartJob  = "text/upstart-job"
+
+	cloudConfigHeader  = "#cloud-config\n"
+	maxCustomDataBytes = 65535
+
+	transferEncoding7bit   = "7bit"
+	transferEncoding8bit   = "8bit"
+	transferEncodingBase64 = "base64"
+	mimeBase64LineLength   = 76
+
+	customDataTooLargeError   = "Custom data is %d bytes long, the maximum allowed size is %d bytes."
+	emptyCloudInitPartsError  = "You should specify at least one cloud-init part"
+	invalidCloudInitPartError = "Cloud-init part %d has no content type."
+)
+
+//Region public methods starts
+
+func SetAzureVMCustomData(azureVMConfiguration *Role, customData []byte) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(customData) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "customData")
+	}
+	if len(customData) > maxCustomDataBytes {
+		return nil, fmt.Errorf(customDataTooLargeError, len(customData), maxCustomDataBytes)
+	}
+
+	configurationSets := azureVMConfiguration.ConfigurationSets.ConfigurationSet
+	for i := 0; i < len(configurationSets); i++ {
+		if !isProvisioningConfigurationSet(configurationSets[i]) {
+			continue
+		}
+
+		configurationSets[i].CustomData = base64.StdEncoding.EncodeToString(customData)
+		return azureVMConfiguration, nil
+	}
+
+	return nil, errors.New(provisioningConfDoesNotExistsError)
+}
+
+func SetAzureVMCloudConfig(azureVMConfiguration *Role, cloudConfig CloudConfig) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+
+	return SetAzureVMCustomData(azureVMConfiguration, CreateCloudConfigData(cloudConfig))
+}
+
+func SetAzureVMCloudInitParts(azureVMConfiguration *Role, parts []CloudInitPart) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+
+	customData, err := CreateCloudInitMultipartData(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	return SetAzureVMCustomData(azureVMConfiguration, customData)
+}
+
+func CreateCloudConfigData(cloudConfig CloudConfig) []byte {
+	var data bytes.Buffer
+	data.WriteString(cloudConfigHeader)
+
+	writeYamlString(&data, "hostname", cloudConfig.Hostname)
+	writeYamlBool(&data, "manage_etc_hosts", cloudConfig.ManageEtcHosts)
+	writeYamlString(&data, "timezone", cloudConfig.Timezone)
+	writeYamlBool(&data, "package_update", cloudConfig.PackageUpdate)
+	writeYamlBool(&data, "package_upgrade", cloudConfig.PackageUpgrade)
+	writeYamlList(&data, "packages", cloudConfig.Packages)
+	writeYamlList(&data, "ssh_authorized_keys", cloudConfig.SshAuthorizedKeys)
+
+	if len(cloudConfig.WriteFiles) > 0 {
+		data.WriteString("write_files:\n")
+		for _, file := range cloudConfig.WriteFiles {
+			data.WriteString("  - path: " + strconv.Quote(file.Path) + "\n")
+			writeYamlString(&data, "    content", file.Content)
+			writeYamlString(&data, "    owner", file.Owner)
+			writeYamlString(&data, "    permissions", file.Permissions)
+			writeYamlString(&data, "    encoding", file.Encoding)
+		}
+	}
+
+	writeYamlList(&data, "bootcmd", cloudConfig.BootCmd)
+	writeYamlList(&data, "runcmd", cloudConfig.RunCmd)
+	writeYamlString(&data, "final_message", cloudConfig.FinalMessage)
+
+	return data.Bytes()
+}
+
+func CreateCloudConfigPart(cloudConfig CloudConfig) CloudInitPart {
+	part := CloudInitPart{}
+	part.ContentType = CloudInitCloudConfig
+	part.FileName = "cloud-config.txt"
+	part.Content = CreateCloudConfigData(cloudConfig)
+
+	return part
+}
+
+func CreateCloudInitMultipartData(parts []CloudInitPart) ([]byte, error) {
+	if len(parts) == 0 {
+		return nil, errors.New(emptyCloudInitPartsError)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for i, part := range parts {
+		if len(part.ContentType) == 0 {
+			return nil, fmt.Errorf(invalidCloudInitPartError, i)
+		}
+
+		fileName := part.FileName
+		if len(fileName) == 0 {
+			fileName = fmt.Sprintf("part-%03d", i+1)
+		}
+
+		charset, transferEncoding := getCloudInitPartEncoding(part.Content)
+		contentType := part.ContentType
+		if len(charset) > 0 {
+			contentType += "; charset=\"" + charset + "\""
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", contentType)
+		header.Set("MIME-Version", "1.0")
+		header.Set("Content-Transfer-Encoding", transferEncoding)
+		header.Set("Content-Disposition", "attachment; filename=\""+fileName+"\"")
+
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+
+		content := part.Content
+		if transferEncoding == transferEncodingBase64 {
+			content = encodeMIMEBase64(content)
+		}
+
+		_, err = partWriter.Write(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var data bytes.Buffer
+	data.WriteString("Content-Type: multipart/mixed; boundary=\"" + writer.Boundary() + "\"\n")
+	data.WriteString("MIME-Version: 1.0\n\n")
+	data.Write(body.Bytes())
+
+	return data.Bytes(), nil
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func isProvisioningConfigurationSet(configurationSet ConfigurationSet) bool {
+	return configurationSet.ConfigurationSetType == "LinuxProvisioningConfiguration" ||
+		configurationSet.ConfigurationSetType == "WindowsProvisioningConfiguration"
+}
+
+// getCloudInitPartEncoding declares ASCII content as 7bit us-ascii and other
+// UTF-8 text as 8bit utf-8. Anything else, such as a gzipped script, is base64
+// encoded without a charset.
+func getCloudInitPartEncoding(content []byte) (string, string) {
+	for _, b := range content {
+		if b >= utf8.RuneSelf {
+			if utf8.Valid(content) {
+				return "utf-8", transferEncoding8bit
+			}
+			return "", transferEncodingBase64
+		}
+	}
+
+	return "us-ascii", transferEncoding7bit
+}
+
+func encodeMIMEBase64(content []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(content)
+
+	var data bytes.Buffer
+	for len(encoded) > mimeBase64LineLength {
+		data.WriteString(encoded[:mimeBase64LineLength] + "\r\n")
+		encoded = encoded[mimeBase64LineLength:]
+	}
+	data.WriteString(encoded)
+
+	return data.Bytes()
+}
+
+func writeYamlString(data *bytes.Buffer, key, value string) {
+	if len(value) == 0 {
+		return
+	}
+
+	data.WriteString(key + ": " + strconv.Quote(value) + "\n")
+}
+
+func writeYamlBool(data *bytes.Buffer, key string, value bool) {
+	if !value {
+		return
+	}
+
+	data.WriteString(key + ": true\n")
+}
+
+func writeYamlList(data *bytes.Buffer, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	data.WriteString(key + ":\n")
+	for _, value := range values {
+		data.WriteString("  - " + strconv.Quote(value) + "\n")
+	}
+}
+
+//Region private methods ends
@@ -0,0 +1,186 @@
+package vmClient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func Test_CreateCloudConfigData(t *testing.T) {
+	cloudConfig := CloudConfig{
+		Hostname:      "web1",
+		PackageUpdate: true,
+		Packages:      []string{"nginx", "git"},
+		WriteFiles:    []CloudConfigFile{{Path: "/etc/motd", Content: "hello \"world\"", Permissions: "0644"}},
+		RunCmd:        []string{"service nginx start"},
+	}
+
+	expected := "#cloud-config\n" +
+		"hostname: \"web1\"\n" +
+		"package_update: true\n" +
+		"packages:\n  - \"nginx\"\n  - \"git\"\n" +
+		"write_files:\n  - path: \"/etc/motd\"\n    content: \"hello \\\"world\\\"\"\n    permissions: \"0644\"\n" +
+		"runcmd:\n  - \"service nginx start\"\n"
+
+	if output := string(CreateCloudConfigData(cloudConfig)); output != expected {
+		t.Errorf("Wrong cloud config. Expected: '%s', got: '%s'", expected, output)
+	}
+
+	if output := string(CreateCloudConfigData(CloudConfig{})); output != cloudConfigHeader {
+		t.Errorf("Empty cloud config should contain only the header, got: '%s'", output)
+	}
+}
+
+func Test_CreateCloudInitMultipartData(t *testing.T) {
+	parts := []CloudInitPart{
+		{ContentType: CloudInitShellScript, FileName: "setup.sh", Content: []byte("#!/bin/sh\necho hi\n")},
+		{ContentType: CloudInitCloudConfig, Content: []byte("#cloud-config\nhostname: web1\n")},
+	}
+
+	data, err := CreateCloudInitMultipartData(parts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Multipart data is not a valid MIME message: %s", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Wrong content type: %s (%v)", mediaType, err)
+	}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	expectedFileNames := []string{"setup.sh", "part-002"}
+	for i, part := range parts {
+		mimePart, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Part %d is missing: %s", i, err)
+		}
+
+		if contentType := mimePart.Header.Get("Content-Type"); !strings.HasPrefix(contentType, part.ContentType) {
+			t.Errorf("Wrong content type for part %d: %s", i, contentType)
+		}
+		if fileName := mimePart.FileName(); fileName != expectedFileNames[i] {
+			t.Errorf("Wrong file name for part %d. Expected: %s, got: %s", i, expectedFileNames[i], fileName)
+		}
+
+		content, _ := ioutil.ReadAll(mimePart)
+		if !bytes.Equal(content, part.Content) {
+			t.Errorf("Wrong content for part %d: '%s'", i, content)
+		}
+	}
+
+	if _, err := reader.NextPart(); err == nil {
+		t.Errorf("Unexpected extra part")
+	}
+}
+
+func Test_CreateCloudInitMultipartDataEncodings(t *testing.T) {
+	binaryContent := []byte{0x1f, 0x8b, 0x08, 0x00, 0xff, 0xfe}
+	binaryContent = append(binaryContent, bytes.Repeat([]byte{0xc3}, 60)...)
+
+	parts := []CloudInitPart{
+		{ContentType: CloudInitShellScript, Content: []byte("#!/bin/sh\necho hi\n")},
+		{ContentType: CloudInitShellScript, Content: []byte("#!/bin/sh\necho 'Grüße, 世界'\n")},
+		{ContentType: CloudInitShellScript, Content: binaryContent},
+	}
+
+	testCases := []struct {
+		contentType      string
+		transferEncoding string
+	}{
+		{CloudInitShellScript + "; charset=\"us-ascii\"", "7bit"},
+		{CloudInitShellScript + "; charset=\"utf-8\"", "8bit"},
+		{CloudInitShellScript, "base64"},
+	}
+
+	data, err := CreateCloudInitMultipartData(parts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Multipart data is not a valid MIME message: %s", err)
+	}
+	_, params, _ := mime.ParseMediaType(message.Header.Get("Content-Type"))
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for i, testCase := range testCases {
+		mimePart, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Part %d is missing: %s", i, err)
+		}
+
+		if contentType := mimePart.Header.Get("Content-Type"); contentType != testCase.contentType {
+			t.Errorf("Wrong content type for part %d. Expected: %s, got: %s", i, testCase.contentType, contentType)
+		}
+		transferEncoding := mimePart.Header.Get("Content-Transfer-Encoding")
+		if transferEncoding != testCase.transferEncoding {
+			t.Errorf("Wrong transfer encoding for part %d. Expected: %s, got: %s", i, testCase.transferEncoding, transferEncoding)
+		}
+
+		content, _ := ioutil.ReadAll(mimePart)
+		if transferEncoding == "base64" {
+			for _, line := range strings.Split(string(content), "\r\n") {
+				if len(line) > 76 {
+					t.Errorf("Part %d has a base64 line of %d characters", i, len(line))
+				}
+			}
+			content, err = base64.StdEncoding.DecodeString(strings.Replace(string(content), "\r\n", "", -1))
+			if err != nil {
+				t.Errorf("Part %d is not valid base64: %s", i, err)
+			}
+		}
+		if !bytes.Equal(content, parts[i].Content) {
+			t.Errorf("Wrong content for part %d: '%s'", i, content)
+		}
+	}
+}
+
+func Test_CreateCloudInitMultipartDataErrors(t *testing.T) {
+	if _, err := CreateCloudInitMultipartData(nil); err == nil {
+		t.Errorf("Expected an error for empty parts")
+	}
+
+	if _, err := CreateCloudInitMultipartData([]CloudInitPart{{Content: []byte("x")}}); err == nil {
+		t.Errorf("Expected an error for a part without content type")
+	}
+}
+
+func Test_SetAzureVMCustomData(t *testing.T) {
+	role := &Role{}
+	role.ConfigurationSets.ConfigurationSet = []ConfigurationSet{
+		{ConfigurationSetType: "NetworkConfiguration"},
+		{ConfigurationSetType: "LinuxProvisioningConfiguration"},
+	}
+
+	_, err := SetAzureVMCustomData(role, []byte("echo hi"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	customData := role.ConfigurationSets.ConfigurationSet[1].CustomData
+	if decoded, _ := base64.StdEncoding.DecodeString(customData); string(decoded) != "echo hi" {
+		t.Errorf("Wrong custom data: %s", customData)
+	}
+	if role.ConfigurationSets.ConfigurationSet[0].CustomData != "" {
+		t.Errorf("Custom data should only be set on the provisioning configuration")
+	}
+
+	if _, err := SetAzureVMCustomData(role, make([]byte, maxCustomDataBytes+1)); err == nil {
+		t.Errorf("Expected an error for oversized custom data")
+	}
+
+	if _, err := SetAzureVMCustomData(&Role{}, []byte("echo hi")); err == nil {
+		t.Errorf("Expected an error for a role without provisioning configuration")
+	}
+}
@@ -0,0 +1,174 @@
+package vmClient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/storage"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/storageServiceClient"
+)
+
+const (
+	defaultVHDContainer        = "vhds"
+	defaultVHDBlobNameTemplate = "{name}-{timestamp}.vhd"
+	vhdTimestampFormat         = "20060102150405"
+	pendingBlobEndpointFormat  = "https://%s.blob.%s/"
+
+	noPlacementStorageServiceError  = "No storage service for new disks was found in %s"
+	placementStorageLocationError   = "Storage service %s is in %s, not in %s"
+	conflictingPlacementPolicyError = "A disk placement policy can not set both a storage service name and a storage service tag"
+	pendingBlobEndpointError        = "Storage service %s has blob endpoint %s instead of %s"
+	noTaggedStorageServiceError     = "No storage service tagged %s was found in %s"
+)
+
+var (
+	diskPlacementPolicy      = DiskPlacementPolicy{Container: defaultVHDContainer, BlobNameTemplate: defaultVHDBlobNameTemplate}
+	diskPlacementCounter     int
+	diskPlacementPolicyMutex sync.Mutex
+)
+
+//Region public methods starts
+
+// SetDiskPlacementPolicy changes where the VHDs of new OS and data disks are
+// created. An empty Container or BlobNameTemplate uses the default.
+func SetDiskPlacementPolicy(policy DiskPlacementPolicy) error {
+	if len(policy.StorageServiceName) > 0 && len(policy.StorageServiceTagName) > 0 {
+		return errors.New(conflictingPlacementPolicyError)
+	}
+
+	if len(policy.Container) == 0 {
+		policy.Container = defaultVHDContainer
+	}
+	if len(policy.BlobNameTemplate) == 0 {
+		policy.BlobNameTemplate = defaultVHDBlobNameTemplate
+	}
+
+	diskPlacementPolicyMutex.Lock()
+	defer diskPlacementPolicyMutex.Unlock()
+
+	diskPlacementPolicy = policy
+	diskPlacementCounter = 0
+	return nil
+}
+
+func GetDiskPlacementPolicy() DiskPlacementPolicy {
+	diskPlacementPolicyMutex.Lock()
+	defer diskPlacementPolicyMutex.Unlock()
+
+	return diskPlacementPolicy
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+// selectPlacementStorageService returns the storage service for a new VHD in
+// location, or nil when one has to be created. A tag policy without a tagged
+// storage service in location is an error.
+func selectPlacementStorageService(policy DiskPlacementPolicy, location string) (*storageServiceClient.StorageService, error) {
+	if len(policy.StorageServiceName) > 0 {
+		storageService, err := storageServiceClient.GetStorageServiceByName(policy.StorageServiceName)
+		if err != nil {
+			return nil, err
+		}
+
+		storageLocation := storageService.StorageServiceProperties.Location
+		if len(storageLocation) > 0 && storageLocation != location {
+			return nil, fmt.Errorf(placementStorageLocationError, policy.StorageServiceName, storageLocation, location)
+		}
+
+		return storageService, nil
+	}
+
+	if len(policy.StorageServiceTagName) == 0 {
+		return storageServiceClient.GetStorageServiceByLocation(location)
+	}
+
+	storageServiceList, err := storageServiceClient.GetStorageServiceList()
+	if err != nil {
+		return nil, err
+	}
+
+	return selectTaggedStorageService(policy, location, storageServiceList.StorageServices)
+}
+
+// selectTaggedStorageService takes turns between the storage services in
+// location carrying the tag of policy.
+func selectTaggedStorageService(policy DiskPlacementPolicy, location string, storageServices []storageServiceClient.StorageService) (*storageServiceClient.StorageService, error) {
+	taggedServices := []storageServiceClient.StorageService{}
+	for _, storageService := range storageServices {
+		if storageService.StorageServiceProperties.Location != location {
+			continue
+		}
+		if !hasStorageServiceTag(storageService, policy.StorageServiceTagName, policy.StorageServiceTagValue) {
+			continue
+		}
+
+		taggedServices = append(taggedServices, storageService)
+	}
+
+	if len(taggedServices) == 0 {
+		// A new storage service would not carry the tag, so every VHD would get its own
+		tag := policy.StorageServiceTagName
+		if len(policy.StorageServiceTagValue) > 0 {
+			tag += "=" + policy.StorageServiceTagValue
+		}
+		return nil, fmt.Errorf(noTaggedStorageServiceError, tag, location)
+	}
+
+	diskPlacementPolicyMutex.Lock()
+	index := diskPlacementCounter % len(taggedServices)
+	diskPlacementCounter++
+	diskPlacementPolicyMutex.Unlock()
+
+	return &taggedServices[index], nil
+}
+
+// createPendingStorageService creates a storage service named by
+// createVHDMediaLink and checks that its blob endpoint is the one the media
+// links were built with.
+func createPendingStorageService(name, location string) error {
+	storageService, err := storageServiceClient.CreateStorageService(name, location)
+	if err != nil {
+		return err
+	}
+
+	blobEndpoint, err := storageServiceClient.GetBlobEndpoint(storageService)
+	if err != nil {
+		return err
+	}
+
+	pendingBlobEndpoint := getPendingBlobEndpoint(name)
+	if !strings.EqualFold(blobEndpoint, pendingBlobEndpoint) {
+		return fmt.Errorf(pendingBlobEndpointError, name, blobEndpoint, pendingBlobEndpoint)
+	}
+
+	return nil
+}
+
+func getPendingBlobEndpoint(name string) string {
+	return fmt.Sprintf(pendingBlobEndpointFormat, name, storage.DefaultBaseUrl)
+}
+
+func hasStorageServiceTag(storageService storageServiceClient.StorageService, tagName, tagValue string) bool {
+	for _, property := range storageService.ExtendedProperties {
+		if property.Name == tagName && (len(tagValue) == 0 || property.Value == tagValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func createVHDBlobName(policy DiskPlacementPolicy, name string) string {
+	replacer := strings.NewReplacer(
+		"{name}", name,
+		"{timestamp}", time.Now().Local().Format(vhdTimestampFormat))
+
+	return replacer.Replace(policy.BlobNameTemplate)
+}
+
+//Region private methods ends
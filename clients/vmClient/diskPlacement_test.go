@@ -0,0 +1,151 @@
+package vmClient
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/storageServiceClient"
+)
+
+func newPlacementTestStorageService(name, location string, tags ...storageServiceClient.ExtendedProperty) storageServiceClient.StorageService {
+	storageService := storageServiceClient.StorageService{ServiceName: name, ExtendedProperties: tags}
+	storageService.StorageServiceProperties.Location = location
+	return storageService
+}
+
+func Test_SetDiskPlacementPolicy(t *testing.T) {
+	defer SetDiskPlacementPolicy(DiskPlacementPolicy{})
+
+	err := SetDiskPlacementPolicy(DiskPlacementPolicy{StorageServiceName: "store", StorageServiceTagName: "vhds"})
+	if err == nil || err.Error() != conflictingPlacementPolicyError {
+		t.Errorf("Expected: %s, got: %v", conflictingPlacementPolicyError, err)
+	}
+
+	err = SetDiskPlacementPolicy(DiskPlacementPolicy{StorageServiceTagName: "vhds"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := DiskPlacementPolicy{StorageServiceTagName: "vhds", Container: defaultVHDContainer, BlobNameTemplate: defaultVHDBlobNameTemplate}
+	if policy := GetDiskPlacementPolicy(); policy != expected {
+		t.Errorf("Expected: %+v, got: %+v", expected, policy)
+	}
+}
+
+func Test_createVHDBlobName(t *testing.T) {
+	testCases := []struct {
+		template string
+		prefix   string
+		suffix   string
+	}{
+		{defaultVHDBlobNameTemplate, "web-", ".vhd"},
+		{"{name}/{timestamp}", "web/", ""},
+		{"{name}.vhd", "web.vhd", ""},
+	}
+
+	for _, testCase := range testCases {
+		before := time.Now().Local().Format(vhdTimestampFormat)
+		output := createVHDBlobName(DiskPlacementPolicy{BlobNameTemplate: testCase.template}, "web")
+		after := time.Now().Local().Format(vhdTimestampFormat)
+
+		if !strings.HasPrefix(output, testCase.prefix) || !strings.HasSuffix(output, testCase.suffix) {
+			t.Errorf("Expected: %s...%s, got: %s", testCase.prefix, testCase.suffix, output)
+			continue
+		}
+
+		timestamp := strings.TrimSuffix(strings.TrimPrefix(output, testCase.prefix), testCase.suffix)
+		if !strings.Contains(testCase.template, "{timestamp}") {
+			if len(timestamp) > 0 {
+				t.Errorf("Expected: no timestamp in %s, got: %s", testCase.template, output)
+			}
+			continue
+		}
+		if timestamp != before && timestamp != after {
+			t.Errorf("Expected: timestamp %s, got: %s", before, timestamp)
+		}
+	}
+}
+
+func Test_hasStorageServiceTag(t *testing.T) {
+	storageService := newPlacementTestStorageService("store", "West US",
+		storageServiceClient.ExtendedProperty{Name: "vhds", Value: "premium"},
+		storageServiceClient.ExtendedProperty{Name: "owner", Value: ""})
+
+	testCases := []struct {
+		tagName  string
+		tagValue string
+		expected bool
+	}{
+		{"vhds", "", true},
+		{"vhds", "premium", true},
+		{"vhds", "standard", false},
+		{"owner", "", true},
+		{"backups", "", false},
+		{"Vhds", "", false},
+	}
+
+	for _, testCase := range testCases {
+		if output := hasStorageServiceTag(storageService, testCase.tagName, testCase.tagValue); output != testCase.expected {
+			t.Errorf("hasStorageServiceTag(%s, %s): expected %v, got %v", testCase.tagName, testCase.tagValue, testCase.expected, output)
+		}
+	}
+}
+
+func Test_selectTaggedStorageService(t *testing.T) {
+	defer SetDiskPlacementPolicy(DiskPlacementPolicy{})
+
+	policy := DiskPlacementPolicy{StorageServiceTagName: "vhds"}
+	SetDiskPlacementPolicy(policy)
+
+	vhdsTag := storageServiceClient.ExtendedProperty{Name: "vhds", Value: "true"}
+	storageServices := []storageServiceClient.StorageService{
+		newPlacementTestStorageService("first", "West US", vhdsTag),
+		newPlacementTestStorageService("untagged", "West US"),
+		newPlacementTestStorageService("elsewhere", "East US", vhdsTag),
+		newPlacementTestStorageService("second", "West US", vhdsTag),
+	}
+
+	expected := []string{"first", "second", "first", "second"}
+	for _, expectedName := range expected {
+		storageService, err := selectTaggedStorageService(policy, "West US", storageServices)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if storageService.ServiceName != expectedName {
+			t.Errorf("Expected: %s, got: %s", expectedName, storageService.ServiceName)
+		}
+	}
+}
+
+func Test_selectTaggedStorageServiceUnmatchedTag(t *testing.T) {
+	storageServices := []storageServiceClient.StorageService{
+		newPlacementTestStorageService("store", "West US", storageServiceClient.ExtendedProperty{Name: "vhds", Value: "standard"}),
+		newPlacementTestStorageService("elsewhere", "East US", storageServiceClient.ExtendedProperty{Name: "vhds", Value: "premium"}),
+	}
+
+	testCases := []struct {
+		policy   DiskPlacementPolicy
+		expected string
+	}{
+		{DiskPlacementPolicy{StorageServiceTagName: "vhds", StorageServiceTagValue: "premium"}, "No storage service tagged vhds=premium was found in West US"},
+		{DiskPlacementPolicy{StorageServiceTagName: "backups"}, "No storage service tagged backups was found in West US"},
+	}
+
+	for _, testCase := range testCases {
+		storageService, err := selectTaggedStorageService(testCase.policy, "West US", storageServices)
+		if storageService != nil {
+			t.Errorf("Expected: no storage service, got: %s", storageService.ServiceName)
+		}
+		if err == nil || err.Error() != testCase.expected {
+			t.Errorf("Expected: %s, got: %v", testCase.expected, err)
+		}
+	}
+}
+
+func Test_getPendingBlobEndpoint(t *testing.T) {
+	expected := "https://portalvhdsabc.blob.core.windows.net/"
+	if output := getPendingBlobEndpoint("portalvhdsabc"); output != expected {
+		t.Errorf("Expected: %s, got: %s", expected, output)
+	}
+}
@@ -0,0 +1,187 @@
+package vmClient
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	dockerCertRsaKeySize     = 2048
+	dockerCertValidityPeriod = 3 * 365 * 24 * time.Hour
+	dockerCAOrganization     = "Docker CA"
+	dockerClientCommonName   = "client"
+	cloudServiceDomainSuffix = ".cloudapp.net"
+
+	dockerCACertFile     = "ca.pem"
+	dockerClientCertFile = "cert.pem"
+	dockerClientKeyFile  = "key.pem"
+)
+
+//Region public methods starts
+
+// GenerateDockerCertificates creates a certificate authority and a server and a
+// client certificate signed by it. The server certificate is valid for hosts.
+// The CA and client certificates are written to certDir as ca.pem, cert.pem and
+// key.pem, the layout expected by "docker --tlsverify" with DOCKER_CERT_PATH.
+func GenerateDockerCertificates(certDir string, hosts []string) (*DockerCertificates, error) {
+	if len(certDir) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "certDir")
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "hosts")
+	}
+
+	caKey, err := rsa.GenerateKey(rand.Reader, dockerCertRsaKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	caTemplate, err := createDockerCertTemplate(pkix.Name{Organization: []string{dockerCAOrganization}})
+	if err != nil {
+		return nil, err
+	}
+	caTemplate.IsCA = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	caBytes, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := x509.ParseCertificate(caBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	serverTemplate, err := createDockerCertTemplate(pkix.Name{CommonName: hosts[0]})
+	if err != nil {
+		return nil, err
+	}
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+
+	serverCert, serverKey, err := createSignedDockerCert(serverTemplate, ca, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientTemplate, err := createDockerCertTemplate(pkix.Name{CommonName: dockerClientCommonName})
+	if err != nil {
+		return nil, err
+	}
+	clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	clientCert, clientKey, err := createSignedDockerCert(clientTemplate, ca, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := new(DockerCertificates)
+	certificates.CACert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caBytes})
+	certificates.ServerCert = serverCert
+	certificates.ServerKey = serverKey
+	certificates.ClientCert = clientCert
+	certificates.ClientKey = clientKey
+
+	err = writeDockerClientCertificates(certDir, certificates)
+	if err != nil {
+		return nil, err
+	}
+
+	return certificates, nil
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func createDockerCertTemplate(subject pkix.Name) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               subject,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(dockerCertValidityPeriod),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	return &template, nil
+}
+
+func createSignedDockerCert(template, ca *x509.Certificate, caKey *rsa.PrivateKey) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, dockerCertRsaKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return certPem, keyPem, nil
+}
+
+func writeDockerClientCertificates(certDir string, certificates *DockerCertificates) error {
+	err := os.MkdirAll(certDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(certDir, dockerCACertFile), certificates.CACert, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(certDir, dockerClientCertFile), certificates.ClientCert, 0644)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(certDir, dockerClientKeyFile), certificates.ClientKey, 0600)
+}
+
+func createDockerPrivateConfig(certificates *DockerCertificates) (string, error) {
+	config := dockerPrivateConfig{}
+	config.CACert = base64.StdEncoding.EncodeToString(certificates.CACert)
+	config.ServerCert = base64.StdEncoding.EncodeToString(certificates.ServerCert)
+	config.ServerKey = base64.StdEncoding.EncodeToString(certificates.ServerKey)
+
+	configJson, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	return string(configJson), nil
+}
+
+//Region private methods ends
@@ -0,0 +1,78 @@
+package vmClient
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_getDockerHosts(t *testing.T) {
+	testCases := map[string][]string{
+		"myservice":          {"myservice.cloudapp.net"},
+		"docker.example.com": {"docker.example.com"},
+	}
+
+	for dnsName, expected := range testCases {
+		if output := getDockerHosts(dnsName); !reflect.DeepEqual(output, expected) {
+			t.Errorf("Wrong hosts for %s. Expected: %v, got: %v", dnsName, expected, output)
+		}
+	}
+}
+
+func Test_GenerateDockerCertificates(t *testing.T) {
+	certDir, err := ioutil.TempDir("", "dockercerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(certDir)
+
+	certificates, err := GenerateDockerCertificates(certDir, []string{"myservice.cloudapp.net"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(certificates.CACert) {
+		t.Fatalf("CA certificate is not valid PEM")
+	}
+
+	serverCert := parseTestPemCertificate(t, certificates.ServerCert)
+	_, err = serverCert.Verify(x509.VerifyOptions{DNSName: "myservice.cloudapp.net", Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	if err != nil {
+		t.Errorf("Server certificate does not verify: %s", err)
+	}
+
+	clientCert := parseTestPemCertificate(t, certificates.ClientCert)
+	_, err = clientCert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		t.Errorf("Client certificate does not verify: %s", err)
+	}
+
+	for _, fileName := range []string{dockerCACertFile, dockerClientCertFile, dockerClientKeyFile} {
+		if _, err := os.Stat(filepath.Join(certDir, fileName)); err != nil {
+			t.Errorf("%s was not written: %s", fileName, err)
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(certDir, dockerClientKeyFile)); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("Client key should only be readable by its owner, mode is %s", info.Mode())
+	}
+}
+
+func parseTestPemCertificate(t *testing.T, data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("Certificate is not valid PEM")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
@@ -2,27 +2,72 @@ package vmClient
 
 import (
 	"encoding/xml"
+	"time"
 )
 
 type VMDeployment struct {
-	XMLName          xml.Name `xml:"Deployment"`
-	Xmlns            string   `xml:"xmlns,attr"`
-	Name             string
-	DeploymentSlot   string
-	Status           string `xml:",omitempty"`
-	Label            string
-	Url              string `xml:",omitempty"`
-	RoleList         RoleList
-	RoleInstanceList RoleInstanceList `xml:",omitempty"`
+	XMLName            xml.Name `xml:"Deployment"`
+	Xmlns              string   `xml:"xmlns,attr"`
+	Name               string
+	DeploymentSlot     string
+	Status             string `xml:",omitempty"`
+	Label              string
+	Url                string `xml:",omitempty"`
+	RoleList           RoleList
+	VirtualNetworkName string           `xml:",omitempty"`
+	ReservedIPName     string           `xml:",omitempty"`
+	RoleInstanceList   RoleInstanceList `xml:",omitempty"`
 }
 
 type HostedServiceDeployment struct {
-	XMLName     xml.Name `xml:"CreateHostedService"`
-	Xmlns       string   `xml:"xmlns,attr"`
-	ServiceName string
-	Label       string
-	Description string
-	Location    string
+	XMLName       xml.Name `xml:"CreateHostedService"`
+	Xmlns         string   `xml:"xmlns,attr"`
+	ServiceName   string
+	Label         string
+	Description   string
+	Location      string `xml:",omitempty"`
+	AffinityGroup string `xml:",omitempty"`
+}
+
+type HostedServiceList struct {
+	XMLName        xml.Name        `xml:"HostedServices"`
+	Xmlns          string          `xml:"xmlns,attr"`
+	HostedServices []HostedService `xml:"HostedService"`
+}
+
+type HostedService struct {
+	Url                     string
+	ServiceName             string
+	HostedServiceProperties HostedServiceProperties
+	Deployments             []VMDeployment `xml:"Deployments>Deployment"`
+}
+
+type HostedServiceProperties struct {
+	Description        string
+	AffinityGroup      string
+	Location           string
+	Label              string
+	Status             string
+	DateCreated        string
+	DateLastModified   string
+	ExtendedProperties ExtendedPropertyList
+}
+
+type HostedServiceUpdate struct {
+	XMLName            xml.Name `xml:"UpdateHostedService"`
+	Xmlns              string   `xml:"xmlns,attr"`
+	Label              string   `xml:",omitempty"`
+	Description        string   `xml:",omitempty"`
+	ExtendedProperties *ExtendedPropertyList
+}
+
+type ExtendedPropertyList struct {
+	ExtendedProperty []ExtendedProperty
+}
+
+type ExtendedProperty struct {
+	Name  string
+	Value string
 }
 
 type RoleList struct {
@@ -34,23 +79,63 @@ type RoleInstanceList struct {
 }
 
 type RoleInstance struct {
-	RoleName       string
-	InstanceName   string
-	InstanceStatus string
-	InstanceSize   string
-	PowerState     string
+	RoleName              string
+	InstanceName          string
+	InstanceStatus        string
+	InstanceUpgradeDomain int
+	InstanceFaultDomain   int
+	InstanceSize          string
+	InstanceStateDetails  string
+	InstanceErrorCode     string
+	IpAddress             string
+	InstanceEndpoints     InstanceEndpoints
+	PowerState            string
+	HostName              string
+}
+
+type InstanceEndpoints struct {
+	InstanceEndpoint []InstanceEndpoint
+}
+
+type InstanceEndpoint struct {
+	Name       string
+	Vip        string
+	PublicPort int
+	LocalPort  int
+	Protocol   string
+}
+
+type RoleInstanceEvent struct {
+	RoleName           string
+	InstanceName       string
+	PreviousStatus     string
+	Status             string
+	PreviousPowerState string
+	PowerState         string
+	Instance           RoleInstance
+	Time               time.Time
+	Err                error
 }
 
 type Role struct {
-	RoleName                    string
-	RoleType                    string
-	ConfigurationSets           ConfigurationSets
-	ResourceExtensionReferences ResourceExtensionReferences `xml:",omitempty"`
-	OSVirtualHardDisk           OSVirtualHardDisk
-	RoleSize                    string
-	ProvisionGuestAgent         bool
-	UseCertAuth                 bool   `xml:"-"`
-	CertPath                    string `xml:"-"`
+	RoleName                      string
+	RoleType                      string
+	ConfigurationSets             ConfigurationSets
+	ResourceExtensionReferences   ResourceExtensionReferences `xml:",omitempty"`
+	VMImageName                   string                      `xml:",omitempty"`
+	MediaLocation                 string                      `xml:",omitempty"`
+	AvailabilitySetName           string                      `xml:",omitempty"`
+	DataVirtualHardDisks          DataVirtualHardDisks        `xml:",omitempty"`
+	OSVirtualHardDisk             OSVirtualHardDisk
+	RoleSize                      string
+	ProvisionGuestAgent           bool
+	UseCertAuth                   bool                 `xml:"-"`
+	CertPath                      string               `xml:"-"`
+	ServiceCertificates           []ServiceCertificate `xml:"-"`
+	VirtualNetworkName            string               `xml:"-"`
+	ReservedIPName                string               `xml:"-"`
+	PendingStorageServiceName     string               `xml:"-"`
+	PendingStorageServiceLocation string               `xml:"-"`
 }
 
 type ConfigurationSets struct {
@@ -84,9 +169,24 @@ type ResourceExtensionParameter struct {
 	Type  string
 }
 
+type DataVirtualHardDisks struct {
+	DataVirtualHardDisk []DataVirtualHardDisk
+}
+
+type DataVirtualHardDisk struct {
+	XMLName             xml.Name `xml:"DataVirtualHardDisk"`
+	Xmlns               string   `xml:"xmlns,attr,omitempty"`
+	HostCaching         string   `xml:",omitempty"`
+	DiskLabel           string   `xml:",omitempty"`
+	DiskName            string   `xml:",omitempty"`
+	Lun                 int
+	LogicalDiskSizeInGB int    `xml:",omitempty"`
+	MediaLink           string `xml:",omitempty"`
+}
+
 type OSVirtualHardDisk struct {
-	MediaLink       string
-	SourceImageName string
+	MediaLink       string `xml:",omitempty"`
+	SourceImageName string `xml:",omitempty"`
 	HostCaching     string `xml:",omitempty"`
 	DiskName        string `xml:",omitempty"`
 	OS              string `xml:",omitempty"`
@@ -99,12 +199,15 @@ type ConfigurationSet struct {
 	UserPassword                     string `xml:",omitempty"`
 	DisableSshPasswordAuthentication bool
 	InputEndpoints                   InputEndpoints `xml:",omitempty"`
+	SubnetNames                      []string       `xml:"SubnetNames>SubnetName,omitempty"`
+	StaticVirtualNetworkIPAddress    string         `xml:",omitempty"`
 	SSH                              SSH            `xml:",omitempty"`
 	CustomData                       string         `xml:",omitempty"`
 }
 
 type SSH struct {
 	PublicKeys PublicKeyList
+	KeyPairs   KeyPairList
 }
 
 type PublicKeyList struct {
@@ -116,6 +219,15 @@ type PublicKey struct {
 	Path        string
 }
 
+type KeyPairList struct {
+	KeyPair []KeyPair
+}
+
+type KeyPair struct {
+	Fingerprint string
+	Path        string
+}
+
 type InputEndpoint struct {
 	LocalPort int
 	Name      string
@@ -132,14 +244,82 @@ type ServiceCertificate struct {
 	Password          string `xml:",omitempty"`
 }
 
+// DiskPlacementPolicy selects the storage service, container and blob name of
+// new VHDs. StorageServiceName pins one storage service. StorageServiceTagName
+// spreads disks round-robin over the storage services in the location having an
+// extended property with that name and, if set, StorageServiceTagValue.
+// BlobNameTemplate may use {name} and {timestamp}.
+type DiskPlacementPolicy struct {
+	StorageServiceName            string
+	StorageServiceTagName         string
+	StorageServiceTagValue        string
+	Container                     string
+	BlobNameTemplate              string
+	DisableStorageServiceCreation bool
+}
+
+type ConnectionInfo struct {
+	RoleName     string
+	InstanceName string
+	OS           string
+	Protocol     string
+	Host         string
+	Vip          string
+	PublicPort   int
+	UserName     string
+}
+
+type CertificateList struct {
+	XMLName      xml.Name      `xml:"Certificates"`
+	Xmlns        string        `xml:"xmlns,attr"`
+	Certificates []Certificate `xml:"Certificate"`
+}
+
+type Certificate struct {
+	CertificateUrl      string
+	Thumbprint          string
+	ThumbprintAlgorithm string
+	Data                string
+}
+
+type CreatedResource struct {
+	Type string
+	Name string
+	Err  error
+}
+
+type VMCreationReport struct {
+	Created    []CreatedResource
+	CleanedUp  []CreatedResource
+	LeftBehind []CreatedResource
+}
+
 type StartRoleOperation struct {
 	Xmlns         string `xml:"xmlns,attr"`
 	OperationType string
 }
 
 type ShutdownRoleOperation struct {
+	Xmlns              string `xml:"xmlns,attr"`
+	OperationType      string
+	PostShutdownAction string `xml:",omitempty"`
+}
+
+type StartRolesOperation struct {
 	Xmlns         string `xml:"xmlns,attr"`
 	OperationType string
+	Roles         RoleNameList
+}
+
+type ShutdownRolesOperation struct {
+	Xmlns              string `xml:"xmlns,attr"`
+	OperationType      string
+	Roles              RoleNameList
+	PostShutdownAction string `xml:",omitempty"`
+}
+
+type RoleNameList struct {
+	Name []string
 }
 
 type RestartRoleOperation struct {
@@ -171,7 +351,151 @@ type RoleSize struct {
 	VirtualMachineResourceDiskSizeInMb int
 }
 
+type ResourceExtensionList struct {
+	XMLName            xml.Name            `xml:"ResourceExtensions"`
+	Xmlns              string              `xml:"xmlns,attr"`
+	ResourceExtensions []ResourceExtension `xml:"ResourceExtension"`
+}
+
+type ResourceExtension struct {
+	Publisher                   string
+	Name                        string
+	Version                     string
+	Label                       string
+	Description                 string
+	PublicConfigurationSchema   string
+	PrivateConfigurationSchema  string
+	SampleConfig                string
+	ReplicationCompleted        bool
+	Eula                        string
+	PrivacyUri                  string
+	HomepageUri                 string
+	IsJsonExtension             bool
+	IsInternalExtension         bool
+	DisallowMajorVersionUpgrade bool
+	SupportedOS                 string
+	CompanyName                 string
+	PublishedDate               string
+}
+
 type dockerPublicConfig struct {
 	DockerPort int `json:"dockerport"`
 	Version    int `json:"version"`
 }
+
+type dockerPrivateConfig struct {
+	CACert     string `json:"ca"`
+	ServerCert string `json:"server-cert"`
+	ServerKey  string `json:"server-key"`
+}
+
+type DockerCertificates struct {
+	CACert     []byte
+	ServerCert []byte
+	ServerKey  []byte
+	ClientCert []byte
+	ClientKey  []byte
+}
+
+type customScriptPublicConfig struct {
+	FileUris []string `json:"fileUris"`
+}
+
+type customScriptPrivateConfig struct {
+	CommandToExecute string `json:"commandToExecute"`
+}
+
+type linuxVMAccessPrivateConfig struct {
+	UserName string `json:"username"`
+	Password string `json:"password,omitempty"`
+	SshKey   string `json:"ssh_key,omitempty"`
+}
+
+type windowsVMAccessPublicConfig struct {
+	UserName string `json:"UserName"`
+}
+
+type windowsVMAccessPrivateConfig struct {
+	Password string `json:"Password"`
+}
+
+type CloudConfig struct {
+	Hostname          string
+	ManageEtcHosts    bool
+	Timezone          string
+	PackageUpdate     bool
+	PackageUpgrade    bool
+	Packages          []string
+	SshAuthorizedKeys []string
+	WriteFiles        []CloudConfigFile
+	BootCmd           []string
+	RunCmd            []string
+	FinalMessage      string
+}
+
+type CloudConfigFile struct {
+	Path        string
+	Content     string
+	Owner       string
+	Permissions string
+	Encoding    string
+}
+
+type CloudInitPart struct {
+	ContentType string
+	FileName    string
+	Content     []byte
+}
+
+type VMSpec struct {
+	Name                 string          `json:"name"`
+	State                string          `json:"state"`
+	Size                 string          `json:"size"`
+	Image                string          `json:"image"`
+	Location             string          `json:"location"`
+	UserName             string          `json:"userName"`
+	Password             string          `json:"password"`
+	SshPort              int             `json:"sshPort"`
+	SshPublicKeys        []string        `json:"sshPublicKeys"`
+	Endpoints            []EndpointSpec  `json:"endpoints"`
+	Extensions           []ExtensionSpec `json:"extensions"`
+	DataDisks            []DataDiskSpec  `json:"dataDisks"`
+	CustomData           string          `json:"customData"`
+	ReplaceOnImageChange bool            `json:"replaceOnImageChange"`
+}
+
+type EndpointSpec struct {
+	Name      string `json:"name"`
+	Protocol  string `json:"protocol"`
+	Port      int    `json:"port"`
+	LocalPort int    `json:"localPort"`
+}
+
+type ExtensionSpec struct {
+	Name          string `json:"name"`
+	Publisher     string `json:"publisher"`
+	Version       string `json:"version"`
+	ReferenceName string `json:"referenceName"`
+	State         string `json:"state"`
+	PublicConfig  string `json:"publicConfig"`
+	PrivateConfig string `json:"privateConfig"`
+}
+
+type DataDiskSpec struct {
+	Lun         int    `json:"lun"`
+	SizeInGB    int    `json:"sizeInGB"`
+	Label       string `json:"label"`
+	HostCaching string `json:"hostCaching"`
+}
+
+type VMPlan struct {
+	Spec    VMSpec
+	Role    *Role
+	Actions []VMPlanAction
+}
+
+type VMPlanAction struct {
+	Type        string
+	Description string
+	DataDisk    *DataVirtualHardDisk
+}
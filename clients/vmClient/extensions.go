@@ -0,0 +1,261 @@
+package vmClient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	azureResourceExtensionListURL     = "services/resourceextensions"
+	azureResourceExtensionVersionsURL = "services/resourceextensions/%s/%s"
+
+	ExtensionVersionLatest = "latest"
+
+	customScriptLinuxExtensionName        = "CustomScriptForLinux"
+	customScriptLinuxExtensionPublisher   = "Microsoft.OSTCExtensions"
+	customScriptWindowsExtensionName      = "CustomScriptExtension"
+	customScriptWindowsExtensionPublisher = "Microsoft.Compute"
+	vmAccessLinuxExtensionName            = "VMAccessForLinux"
+	vmAccessLinuxExtensionPublisher       = "Microsoft.OSTCExtensions"
+	vmAccessWindowsExtensionName          = "VMAccessAgent"
+	vmAccessWindowsExtensionPublisher     = "Microsoft.Compute"
+	dockerExtensionName                   = "DockerExtension"
+	dockerExtensionPublisher              = "MSOpenTech.Extensions"
+
+	// Wildcard versions let Azure upgrade the extension to new minor versions
+	customScriptLinuxDefaultVersion   = "1.*"
+	customScriptWindowsDefaultVersion = "1.*"
+	vmAccessLinuxDefaultVersion       = "1.*"
+	vmAccessWindowsDefaultVersion     = "1.*"
+	dockerExtensionDefaultVersion     = "0.3"
+
+	extensionVersionNotFoundError = "No version of extension %s/%s matches %s"
+	invalidAccessCredentialsError = "You should specify a password or an SSH public key to reset VM access"
+)
+
+//Region public methods starts
+
+func GetResourceExtensionList() (*ResourceExtensionList, error) {
+	return getResourceExtensionList(azureResourceExtensionListURL, false)
+}
+
+func GetResourceExtensionVersions(publisher, name string) (*ResourceExtensionList, error) {
+	if len(publisher) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "publisher")
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	return getResourceExtensionList(fmt.Sprintf(azureResourceExtensionVersionsURL, publisher, name), false)
+}
+
+// ResolveExtensionVersion returns the highest published version of the
+// extension matching version, which may be "latest", empty, an exact version
+// or a wildcard such as "1.*". SetAzureVMExtension sends versions unchanged, so
+// callers pinning an exact version resolve it here first.
+func ResolveExtensionVersion(publisher, name, version string) (string, error) {
+	if len(publisher) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "publisher")
+	}
+	if len(name) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	extensionList, err := getResourceExtensionList(fmt.Sprintf(azureResourceExtensionVersionsURL, publisher, name), true)
+	if err != nil {
+		return "", err
+	}
+
+	resolvedVersion := ""
+	for _, extension := range extensionList.ResourceExtensions {
+		if !matchExtensionVersion(extension.Version, version) {
+			continue
+		}
+
+		if len(resolvedVersion) == 0 || compareExtensionVersions(extension.Version, resolvedVersion) > 0 {
+			resolvedVersion = extension.Version
+		}
+	}
+
+	if len(resolvedVersion) == 0 {
+		return "", fmt.Errorf(extensionVersionNotFoundError, publisher, name, version)
+	}
+
+	return resolvedVersion, nil
+}
+
+func SetAzureVMCustomScriptExtension(azureVMConfiguration *Role, os string, fileUris []string, commandToExecute string, version string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(commandToExecute) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "commandToExecute")
+	}
+
+	var name, publisher, defaultVersion string
+	switch os {
+	case osLinux:
+		name, publisher, defaultVersion = customScriptLinuxExtensionName, customScriptLinuxExtensionPublisher, customScriptLinuxDefaultVersion
+	case osWindows:
+		name, publisher, defaultVersion = customScriptWindowsExtensionName, customScriptWindowsExtensionPublisher, customScriptWindowsDefaultVersion
+	default:
+		return nil, errors.New(invalidOSError)
+	}
+
+	if len(version) == 0 {
+		version = defaultVersion
+	}
+
+	publicConfiguration, privateConfiguration, err := createCustomScriptConfig(fileUris, commandToExecute)
+	if err != nil {
+		return nil, err
+	}
+
+	return SetAzureVMExtension(azureVMConfiguration, name, publisher, version, name, "enable", publicConfiguration, privateConfiguration)
+}
+
+func SetAzureVMAccessExtension(azureVMConfiguration *Role, os string, userName, password, sshPublicKey string, version string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(userName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "userName")
+	}
+	if len(password) == 0 && len(sshPublicKey) == 0 {
+		return nil, errors.New(invalidAccessCredentialsError)
+	}
+
+	var name, publisher, defaultVersion, publicConfiguration, privateConfiguration string
+	var err error
+	switch os {
+	case osLinux:
+		name, publisher, defaultVersion = vmAccessLinuxExtensionName, vmAccessLinuxExtensionPublisher, vmAccessLinuxDefaultVersion
+		publicConfiguration, privateConfiguration, err = createLinuxVMAccessConfig(userName, password, sshPublicKey)
+	case osWindows:
+		name, publisher, defaultVersion = vmAccessWindowsExtensionName, vmAccessWindowsExtensionPublisher, vmAccessWindowsDefaultVersion
+		publicConfiguration, privateConfiguration, err = createWindowsVMAccessConfig(userName, password)
+	default:
+		return nil, errors.New(invalidOSError)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(version) == 0 {
+		version = defaultVersion
+	}
+
+	return SetAzureVMExtension(azureVMConfiguration, name, publisher, version, name, "enable", publicConfiguration, privateConfiguration)
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func getResourceExtensionList(requestURL string, cached bool) (*ResourceExtensionList, error) {
+	extensionList := new(ResourceExtensionList)
+
+	response, err := azure.SendAzureGetRequestWithCache(requestURL, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, extensionList)
+	if err != nil {
+		return nil, err
+	}
+
+	return extensionList, nil
+}
+
+func matchExtensionVersion(version, query string) bool {
+	if len(query) == 0 || query == ExtensionVersionLatest {
+		return true
+	}
+
+	versionParts := strings.Split(version, ".")
+	queryParts := strings.Split(query, ".")
+	for i, queryPart := range queryParts {
+		if queryPart == "*" {
+			return true
+		}
+		if i >= len(versionParts) || versionParts[i] != queryPart {
+			return false
+		}
+	}
+
+	return len(versionParts) == len(queryParts)
+}
+
+func compareExtensionVersions(first, second string) int {
+	firstParts := strings.Split(first, ".")
+	secondParts := strings.Split(second, ".")
+
+	for i := 0; i < len(firstParts) || i < len(secondParts); i++ {
+		firstNumber, secondNumber := 0, 0
+		if i < len(firstParts) {
+			firstNumber, _ = strconv.Atoi(firstParts[i])
+		}
+		if i < len(secondParts) {
+			secondNumber, _ = strconv.Atoi(secondParts[i])
+		}
+
+		if firstNumber != secondNumber {
+			return firstNumber - secondNumber
+		}
+	}
+
+	return 0
+}
+
+func createCustomScriptConfig(fileUris []string, commandToExecute string) (string, string, error) {
+	publicConfig := customScriptPublicConfig{FileUris: fileUris}
+	if publicConfig.FileUris == nil {
+		publicConfig.FileUris = []string{}
+	}
+
+	// The command is kept in the private configuration so that secrets passed
+	// as arguments are encrypted and never returned by the management API
+	privateConfig := customScriptPrivateConfig{CommandToExecute: commandToExecute}
+
+	return marshalExtensionConfig(publicConfig, privateConfig)
+}
+
+func createLinuxVMAccessConfig(userName, password, sshPublicKey string) (string, string, error) {
+	privateConfig := linuxVMAccessPrivateConfig{}
+	privateConfig.UserName = userName
+	privateConfig.Password = password
+	privateConfig.SshKey = sshPublicKey
+
+	return marshalExtensionConfig(struct{}{}, privateConfig)
+}
+
+func createWindowsVMAccessConfig(userName, password string) (string, string, error) {
+	publicConfig := windowsVMAccessPublicConfig{UserName: userName}
+	privateConfig := windowsVMAccessPrivateConfig{Password: password}
+
+	return marshalExtensionConfig(publicConfig, privateConfig)
+}
+
+func marshalExtensionConfig(publicConfig, privateConfig interface{}) (string, string, error) {
+	publicConfigJson, err := json.Marshal(publicConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	privateConfigJson, err := json.Marshal(privateConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(publicConfigJson), string(privateConfigJson), nil
+}
+
+//Region private methods ends
@@ -0,0 +1,114 @@
+package vmClient
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func Test_matchExtensionVersion(t *testing.T) {
+	testCases := []struct {
+		version  string
+		query    string
+		expected bool
+	}{
+		{"1.2", "", true},
+		{"1.2", ExtensionVersionLatest, true},
+		{"1.2", "1.2", true},
+		{"1.2", "1.3", false},
+		{"1.2", "1.*", true},
+		{"1.2.3", "1.*", true},
+		{"2.0", "1.*", false},
+		{"1.2.3", "1.2", false},
+		{"1", "1.2", false},
+		{"1.2", "*", true},
+	}
+
+	for _, testCase := range testCases {
+		if output := matchExtensionVersion(testCase.version, testCase.query); output != testCase.expected {
+			t.Errorf("matchExtensionVersion(%s, %s): expected %v, got %v", testCase.version, testCase.query, testCase.expected, output)
+		}
+	}
+}
+
+func Test_compareExtensionVersions(t *testing.T) {
+	testCases := []struct {
+		first    string
+		second   string
+		expected int
+	}{
+		{"1.2", "1.2", 0},
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"2.0", "1.99", 1},
+		{"1.2", "1.2.0", 0},
+		{"1.2", "1.2.1", -1},
+	}
+
+	for _, testCase := range testCases {
+		output := compareExtensionVersions(testCase.first, testCase.second)
+		if (output > 0 && testCase.expected <= 0) || (output < 0 && testCase.expected >= 0) || (output == 0 && testCase.expected != 0) {
+			t.Errorf("compareExtensionVersions(%s, %s): expected sign %d, got %d", testCase.first, testCase.second, testCase.expected, output)
+		}
+	}
+}
+
+func Test_SetAzureVMExtensionKeepsVersion(t *testing.T) {
+	role, err := SetAzureVMExtension(&Role{}, "CustomScriptForLinux", "Microsoft.OSTCExtensions", "1.*", "script", "enable", "{}", "{\"a\":1}")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	extension := role.ResourceExtensionReferences.ResourceExtensionReference[0]
+	if extension.Version != "1.*" {
+		t.Errorf("Wildcard version should be sent unchanged, got %s", extension.Version)
+	}
+
+	parameters := extension.ResourceExtensionParameterValues.ResourceExtensionParameterValue
+	if len(parameters) != 2 || parameters[0].Type != "Private" || parameters[1].Type != "Public" {
+		t.Fatalf("Wrong parameters: %+v", parameters)
+	}
+	if value, _ := base64.StdEncoding.DecodeString(parameters[0].Value); string(value) != "{\"a\":1}" {
+		t.Errorf("Wrong private configuration: %s", value)
+	}
+
+	if _, err := SetAzureVMExtension(&Role{}, "CustomScriptForLinux", "Microsoft.OSTCExtensions", "", "script", "enable", "", ""); err == nil {
+		t.Errorf("Expected an error for an empty version")
+	}
+}
+
+func Test_SetAzureVMTypedExtensions(t *testing.T) {
+	role, err := SetAzureVMCustomScriptExtension(&Role{}, osLinux, []string{"https://host/setup.sh"}, "sh setup.sh", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	extension := role.ResourceExtensionReferences.ResourceExtensionReference[0]
+	if extension.Name != customScriptLinuxExtensionName || extension.Version != customScriptLinuxDefaultVersion {
+		t.Errorf("Wrong custom script extension: %s %s", extension.Name, extension.Version)
+	}
+
+	parameters := extension.ResourceExtensionParameterValues.ResourceExtensionParameterValue
+	privateConfig, _ := base64.StdEncoding.DecodeString(parameters[0].Value)
+	publicConfig, _ := base64.StdEncoding.DecodeString(parameters[1].Value)
+	if string(privateConfig) != `{"commandToExecute":"sh setup.sh"}` {
+		t.Errorf("Wrong private configuration: %s", privateConfig)
+	}
+	if string(publicConfig) != `{"fileUris":["https://host/setup.sh"]}` {
+		t.Errorf("Wrong public configuration: %s", publicConfig)
+	}
+
+	role, err = SetAzureVMAccessExtension(&Role{}, osWindows, "admin", "secret", "", "2.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if extension := role.ResourceExtensionReferences.ResourceExtensionReference[0]; extension.Name != vmAccessWindowsExtensionName || extension.Version != "2.0" {
+		t.Errorf("Wrong VM access extension: %s %s", extension.Name, extension.Version)
+	}
+
+	if _, err := SetAzureVMAccessExtension(&Role{}, osLinux, "admin", "", "", ""); err == nil {
+		t.Errorf("Expected an error without password or SSH key")
+	}
+	if _, err := SetAzureVMCustomScriptExtension(&Role{}, "Plan9", nil, "ls", ""); err == nil {
+		t.Errorf("Expected an error for an unknown OS")
+	}
+}
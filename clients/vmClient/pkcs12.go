@@ -0,0 +1,305 @@
+package vmClient
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"unicode/utf16"
+)
+
+const (
+	pkcs12Version         = 3
+	pkcs12Iterations      = 2048
+	pkcs12SaltSize        = 8
+	pkcs12EncryptionKeyId = 1
+	pkcs12EncryptionIVId  = 2
+	pkcs12MacKeyId        = 3
+)
+
+var (
+	oidDataContentType            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPkcs8ShroudedKeyBag        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509Certificate            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidLocalKeyId                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidPbeWithSHAAnd3KeyTripleDES = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidSHA1                       = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+)
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+type digestInfo struct {
+	Algorithm algorithmIdentifier
+	Digest    []byte
+}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type safeBag struct {
+	Id         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	Id    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	Id   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     algorithmIdentifier
+	EncryptedData []byte
+}
+
+//Region private methods starts
+
+// createPfx builds a PKCS#12 archive holding the certificate and its private key,
+// protected by an empty password. The key is encrypted with
+// pbeWithSHAAnd3-KeyTripleDES-CBC and the archive is integrity protected with
+// an HMAC-SHA1, as described in RFC 7292.
+func createPfx(certData []byte, privateKey *rsa.PrivateKey) ([]byte, error) {
+	password := encodePkcs12Password("")
+	localKeyId := sha1.Sum(certData)
+
+	localKeyIdValue, err := asn1.Marshal(localKeyId[:])
+	if err != nil {
+		return nil, err
+	}
+	attributes := []pkcs12Attribute{{Id: oidLocalKeyId, Value: asn1.RawValue{FullBytes: wrapSet(localKeyIdValue)}}}
+
+	keyBag, err := createShroudedKeyBag(privateKey, password, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	certBagValue, err := asn1.Marshal(certBag{Id: oidX509Certificate, Data: certData})
+	if err != nil {
+		return nil, err
+	}
+	certSafeBag := safeBag{Id: oidCertBag, Value: asn1.RawValue{FullBytes: wrapExplicitTag(certBagValue)}, Attributes: attributes}
+
+	keySafeContents, err := asn1.Marshal([]safeBag{keyBag})
+	if err != nil {
+		return nil, err
+	}
+	certSafeContents, err := asn1.Marshal([]safeBag{certSafeBag})
+	if err != nil {
+		return nil, err
+	}
+
+	keyContentInfo, err := createDataContentInfo(keySafeContents)
+	if err != nil {
+		return nil, err
+	}
+	certContentInfo, err := createDataContentInfo(certSafeContents)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticatedSafe, err := asn1.Marshal([]contentInfo{certContentInfo, keyContentInfo})
+	if err != nil {
+		return nil, err
+	}
+
+	authSafe, err := createDataContentInfo(authenticatedSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	macSalt := make([]byte, pkcs12SaltSize)
+	_, err = rand.Read(macSalt)
+	if err != nil {
+		return nil, err
+	}
+
+	macKey := derivePkcs12Key(macSalt, password, pkcs12Iterations, pkcs12MacKeyId, sha1.Size)
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(authenticatedSafe)
+
+	pfx := pfxPdu{}
+	pfx.Version = pkcs12Version
+	pfx.AuthSafe = authSafe
+	pfx.MacData.Mac.Algorithm = algorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue}
+	pfx.MacData.Mac.Digest = mac.Sum(nil)
+	pfx.MacData.MacSalt = macSalt
+	pfx.MacData.Iterations = pkcs12Iterations
+
+	return asn1.Marshal(pfx)
+}
+
+func createShroudedKeyBag(privateKey *rsa.PrivateKey, password []byte, attributes []pkcs12Attribute) (safeBag, error) {
+	bag := safeBag{}
+
+	keyData, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return bag, err
+	}
+
+	salt := make([]byte, pkcs12SaltSize)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return bag, err
+	}
+
+	params, err := asn1.Marshal(pbeParams{Salt: salt, Iterations: pkcs12Iterations})
+	if err != nil {
+		return bag, err
+	}
+
+	encryptedData, err := encryptPkcs12Data(keyData, salt, password, pkcs12Iterations)
+	if err != nil {
+		return bag, err
+	}
+
+	keyInfo := encryptedPrivateKeyInfo{}
+	keyInfo.Algorithm = algorithmIdentifier{Algorithm: oidPbeWithSHAAnd3KeyTripleDES, Parameters: asn1.RawValue{FullBytes: params}}
+	keyInfo.EncryptedData = encryptedData
+
+	keyInfoData, err := asn1.Marshal(keyInfo)
+	if err != nil {
+		return bag, err
+	}
+
+	bag.Id = oidPkcs8ShroudedKeyBag
+	bag.Value = asn1.RawValue{FullBytes: wrapExplicitTag(keyInfoData)}
+	bag.Attributes = attributes
+	return bag, nil
+}
+
+func createDataContentInfo(data []byte) (contentInfo, error) {
+	content := contentInfo{}
+
+	octets, err := asn1.Marshal(data)
+	if err != nil {
+		return content, err
+	}
+
+	content.ContentType = oidDataContentType
+	content.Content = asn1.RawValue{FullBytes: wrapExplicitTag(octets)}
+	return content, nil
+}
+
+func encryptPkcs12Data(data, salt, password []byte, iterations int) ([]byte, error) {
+	key := derivePkcs12Key(salt, password, iterations, pkcs12EncryptionKeyId, 24)
+	iv := derivePkcs12Key(salt, password, iterations, pkcs12EncryptionIVId, des.BlockSize)
+
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := des.BlockSize - len(data)%des.BlockSize
+	encrypted := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	return encrypted, nil
+}
+
+// derivePkcs12Key implements the SHA-1 key derivation of RFC 7292, appendix B.2.
+func derivePkcs12Key(salt, password []byte, iterations int, id byte, size int) []byte {
+	const v = 64
+
+	d := bytes.Repeat([]byte{id}, v)
+	i := append(fillPkcs12Block(salt, v), fillPkcs12Block(password, v)...)
+
+	one := big.NewInt(1)
+	result := []byte{}
+	for len(result) < size {
+		hash := sha1.Sum(append(append([]byte{}, d...), i...))
+		a := hash[:]
+		for n := 1; n < iterations; n++ {
+			hash = sha1.Sum(a)
+			a = hash[:]
+		}
+		result = append(result, a...)
+
+		b := new(big.Int).SetBytes(fillPkcs12Block(a, v))
+		for j := 0; j < len(i); j += v {
+			block := new(big.Int).SetBytes(i[j : j+v])
+			block.Add(block, b)
+			block.Add(block, one)
+
+			blockBytes := block.Bytes()
+			if len(blockBytes) > v {
+				blockBytes = blockBytes[len(blockBytes)-v:]
+			}
+
+			copy(i[j:j+v], make([]byte, v))
+			copy(i[j+v-len(blockBytes):j+v], blockBytes)
+		}
+	}
+
+	return result[:size:size]
+}
+
+// fillPkcs12Block repeats data to the smallest multiple of v bytes holding it.
+func fillPkcs12Block(data []byte, v int) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+
+	size := v * ((len(data) + v - 1) / v)
+	filled := make([]byte, size)
+	for i := range filled {
+		filled[i] = data[i%len(data)]
+	}
+
+	return filled
+}
+
+// encodePkcs12Password returns the password as a null terminated BMPString.
+func encodePkcs12Password(password string) []byte {
+	encoded := []byte{}
+	for _, char := range utf16.Encode([]rune(password)) {
+		encoded = append(encoded, byte(char>>8), byte(char))
+	}
+
+	return append(encoded, 0, 0)
+}
+
+func wrapExplicitTag(data []byte) []byte {
+	wrapped, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: data})
+	return wrapped
+}
+
+func wrapSet(data []byte) []byte {
+	set, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: data})
+	return set
+}
+
+//Region private methods ends
@@ -0,0 +1,136 @@
+package vmClient
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"testing"
+)
+
+func Test_derivePkcs12Key(t *testing.T) {
+	salt, _ := hex.DecodeString("ffffffffffffffff")
+	macSalt, _ := hex.DecodeString("0102030405060708")
+
+	testCases := []struct {
+		salt       []byte
+		password   string
+		iterations int
+		id         byte
+		size       int
+		expected   string
+	}{
+		{salt, "sesame", 2048, pkcs12EncryptionKeyId, 24, "7cd9fd3e2b3be7691a44e3bef0f9ea0fb9b897d4e325d9d1"},
+		{macSalt, "", 1, pkcs12MacKeyId, 20, "b73b61d3a22867cf1922c1d70e96c7567ff156bd"},
+	}
+
+	for _, testCase := range testCases {
+		key := derivePkcs12Key(testCase.salt, encodePkcs12Password(testCase.password), testCase.iterations, testCase.id, testCase.size)
+		if output := hex.EncodeToString(key); output != testCase.expected {
+			t.Errorf("Wrong key for password '%s'. Expected: %s, got: %s", testCase.password, testCase.expected, output)
+		}
+	}
+}
+
+func Test_encodePkcs12Password(t *testing.T) {
+	testCases := map[string]string{
+		"":   "0000",
+		"ab": "006100620000",
+		"é":  "00e90000",
+	}
+
+	for password, expected := range testCases {
+		if output := hex.EncodeToString(encodePkcs12Password(password)); output != expected {
+			t.Errorf("Wrong encoding for '%s'. Expected: %s, got: %s", password, expected, output)
+		}
+	}
+}
+
+func Test_createPfx(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certData, err := createSshPublicKeyCertificate(&privateKey.PublicKey, privateKey, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pfxData, err := createPfx(certData, privateKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	pfx := pfxPdu{}
+	if _, err := asn1.Unmarshal(pfxData, &pfx); err != nil {
+		t.Fatalf("PFX is not valid ASN.1: %s", err)
+	}
+
+	var authenticatedSafe []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authenticatedSafe); err != nil {
+		t.Fatal(err)
+	}
+
+	password := encodePkcs12Password("")
+	macKey := derivePkcs12Key(pfx.MacData.MacSalt, password, pfx.MacData.Iterations, pkcs12MacKeyId, sha1.Size)
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(authenticatedSafe)
+	if !hmac.Equal(mac.Sum(nil), pfx.MacData.Mac.Digest) {
+		t.Errorf("PFX MAC does not verify")
+	}
+
+	contentInfos := []contentInfo{}
+	if _, err := asn1.Unmarshal(authenticatedSafe, &contentInfos); err != nil || len(contentInfos) != 2 {
+		t.Fatalf("Wrong authenticated safe: %v", err)
+	}
+
+	var certContents, keyContents []byte
+	asn1.Unmarshal(contentInfos[0].Content.Bytes, &certContents)
+	asn1.Unmarshal(contentInfos[1].Content.Bytes, &keyContents)
+
+	certBags := []safeBag{}
+	if _, err := asn1.Unmarshal(certContents, &certBags); err != nil || len(certBags) != 1 {
+		t.Fatalf("Wrong certificate safe contents: %v", err)
+	}
+	bag := certBag{}
+	if _, err := asn1.Unmarshal(certBags[0].Value.Bytes, &bag); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bag.Data, certData) {
+		t.Errorf("Certificate in PFX does not match")
+	}
+
+	keyBags := []safeBag{}
+	if _, err := asn1.Unmarshal(keyContents, &keyBags); err != nil || len(keyBags) != 1 {
+		t.Fatalf("Wrong key safe contents: %v", err)
+	}
+	keyInfo := encryptedPrivateKeyInfo{}
+	if _, err := asn1.Unmarshal(keyBags[0].Value.Bytes, &keyInfo); err != nil {
+		t.Fatal(err)
+	}
+	params := pbeParams{}
+	if _, err := asn1.Unmarshal(keyInfo.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+
+	key := derivePkcs12Key(params.Salt, password, params.Iterations, pkcs12EncryptionKeyId, 24)
+	iv := derivePkcs12Key(params.Salt, password, params.Iterations, pkcs12EncryptionIVId, des.BlockSize)
+	block, _ := des.NewTripleDESCipher(key)
+	decrypted := make([]byte, len(keyInfo.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, keyInfo.EncryptedData)
+	decrypted = decrypted[:len(decrypted)-int(decrypted[len(decrypted)-1])]
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(decrypted)
+	if err != nil {
+		t.Fatalf("Decrypted key is not PKCS#8: %s", err)
+	}
+	if parsedKey.(*rsa.PrivateKey).N.Cmp(privateKey.N) != 0 {
+		t.Errorf("Private key in PFX does not match")
+	}
+}
@@ -0,0 +1,235 @@
+package vmClient
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	InstanceStatusReadyRole           = "ReadyRole"
+	InstanceStatusCyclingRole         = "CyclingRole"
+	InstanceStatusFailedStartingRole  = "FailedStartingRole"
+	InstanceStatusFailedStartingVM    = "FailedStartingVM"
+	InstanceStatusProvisioningFailed  = "ProvisioningFailed"
+	InstanceStatusProvisioningTimeout = "ProvisioningTimeout"
+
+	defaultWatcherPollInterval = 5 * time.Second
+
+	roleInstanceFailedError  = "Role instance %s is in state %s: %s %s"
+	roleInstanceTimeoutError = "Timed out waiting for role %s to become ready, current status is %s"
+	watcherStoppedError      = "Role instance watcher was stopped"
+)
+
+var failedInstanceStatuses = map[string]bool{
+	InstanceStatusCyclingRole:         true,
+	InstanceStatusFailedStartingRole:  true,
+	InstanceStatusFailedStartingVM:    true,
+	InstanceStatusProvisioningFailed:  true,
+	InstanceStatusProvisioningTimeout: true,
+}
+
+type RoleInstanceWatcher struct {
+	cloudserviceName string
+	deploymentName   string
+	pollInterval     time.Duration
+	events           chan<- RoleInstanceEvent
+
+	mutex     sync.Mutex
+	instances map[string]RoleInstance
+	lastErr   error
+	updated   chan struct{}
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+//Region public methods starts
+
+// NewRoleInstanceWatcher polls the deployment every pollInterval and sends an
+// event to events, if it is not nil, whenever the status or power state of a
+// role instance changes. The caller must receive from events until Stop is called.
+func NewRoleInstanceWatcher(cloudserviceName, deploymentName string, pollInterval time.Duration, events chan<- RoleInstanceEvent) (*RoleInstanceWatcher, error) {
+	if len(cloudserviceName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+
+	if pollInterval <= 0 {
+		pollInterval = defaultWatcherPollInterval
+	}
+
+	deployment, err := GetVMDeployment(cloudserviceName, deploymentName)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &RoleInstanceWatcher{
+		cloudserviceName: cloudserviceName,
+		deploymentName:   deploymentName,
+		pollInterval:     pollInterval,
+		events:           events,
+		instances:        make(map[string]RoleInstance),
+		updated:          make(chan struct{}),
+		stop:             make(chan struct{}),
+	}
+
+	go watcher.watch(deployment)
+	return watcher, nil
+}
+
+func WaitForRoleReady(cloudserviceName, deploymentName, roleName string, timeout time.Duration) (*RoleInstance, error) {
+	watcher, err := NewRoleInstanceWatcher(cloudserviceName, deploymentName, defaultWatcherPollInterval, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+
+	return watcher.WaitForReady(roleName, timeout)
+}
+
+func (watcher *RoleInstanceWatcher) WaitForReady(roleName string, timeout time.Duration) (*RoleInstance, error) {
+	if len(roleName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	}
+
+	deadline := time.After(timeout)
+	for {
+		watcher.mutex.Lock()
+		instance, found := watcher.findRoleInstance(roleName)
+		updated := watcher.updated
+		lastErr := watcher.lastErr
+		watcher.mutex.Unlock()
+
+		if found && instance.InstanceStatus == InstanceStatusReadyRole {
+			return &instance, nil
+		}
+		if found && failedInstanceStatuses[instance.InstanceStatus] {
+			return nil, fmt.Errorf(roleInstanceFailedError, instance.InstanceName, instance.InstanceStatus, instance.InstanceErrorCode, instance.InstanceStateDetails)
+		}
+
+		select {
+		case <-updated:
+		case <-deadline:
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, fmt.Errorf(roleInstanceTimeoutError, roleName, instance.InstanceStatus)
+		case <-watcher.stop:
+			return nil, errors.New(watcherStoppedError)
+		}
+	}
+}
+
+func (watcher *RoleInstanceWatcher) Stop() {
+	watcher.stopOnce.Do(func() {
+		close(watcher.stop)
+	})
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func (watcher *RoleInstanceWatcher) watch(deployment *VMDeployment) {
+	for {
+		watcher.update(deployment)
+
+		select {
+		case <-watcher.stop:
+			return
+		case <-time.After(watcher.pollInterval):
+		}
+
+		var err error
+		deployment, err = GetVMDeployment(watcher.cloudserviceName, watcher.deploymentName)
+		if err != nil {
+			watcher.updateError(err)
+			deployment = nil
+		}
+	}
+}
+
+func (watcher *RoleInstanceWatcher) update(deployment *VMDeployment) {
+	if deployment == nil {
+		return
+	}
+
+	now := time.Now()
+	events := []RoleInstanceEvent{}
+
+	watcher.mutex.Lock()
+	instances := make(map[string]RoleInstance)
+	for _, instance := range deployment.RoleInstanceList.RoleInstance {
+		previous := watcher.instances[instance.InstanceName]
+		instances[instance.InstanceName] = *instance
+
+		if previous.InstanceStatus == instance.InstanceStatus && previous.PowerState == instance.PowerState {
+			continue
+		}
+
+		event := RoleInstanceEvent{}
+		event.RoleName = instance.RoleName
+		event.InstanceName = instance.InstanceName
+		event.PreviousStatus = previous.InstanceStatus
+		event.Status = instance.InstanceStatus
+		event.PreviousPowerState = previous.PowerState
+		event.PowerState = instance.PowerState
+		event.Instance = *instance
+		event.Time = now
+		events = append(events, event)
+	}
+
+	watcher.instances = instances
+	watcher.lastErr = nil
+	watcher.broadcast()
+	watcher.mutex.Unlock()
+
+	for _, event := range events {
+		watcher.sendEvent(event)
+	}
+}
+
+func (watcher *RoleInstanceWatcher) updateError(err error) {
+	watcher.mutex.Lock()
+	watcher.lastErr = err
+	watcher.broadcast()
+	watcher.mutex.Unlock()
+
+	event := RoleInstanceEvent{}
+	event.Time = time.Now()
+	event.Err = err
+	watcher.sendEvent(event)
+}
+
+func (watcher *RoleInstanceWatcher) broadcast() {
+	close(watcher.updated)
+	watcher.updated = make(chan struct{})
+}
+
+func (watcher *RoleInstanceWatcher) sendEvent(event RoleInstanceEvent) {
+	if watcher.events == nil {
+		return
+	}
+
+	select {
+	case watcher.events <- event:
+	case <-watcher.stop:
+	}
+}
+
+func (watcher *RoleInstanceWatcher) findRoleInstance(roleName string) (RoleInstance, bool) {
+	for _, instance := range watcher.instances {
+		if instance.RoleName == roleName {
+			return instance, true
+		}
+	}
+
+	return RoleInstance{}, false
+}
+
+//Region private methods ends
@@ -0,0 +1,209 @@
+package vmClient
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestRoleInstanceWatcher(events chan<- RoleInstanceEvent) *RoleInstanceWatcher {
+	return &RoleInstanceWatcher{
+		cloudserviceName: "service",
+		deploymentName:   "deployment",
+		pollInterval:     time.Hour,
+		events:           events,
+		instances:        make(map[string]RoleInstance),
+		updated:          make(chan struct{}),
+		stop:             make(chan struct{}),
+	}
+}
+
+func newWatcherTestDeployment(instances ...RoleInstance) *VMDeployment {
+	deployment := &VMDeployment{}
+	for i := range instances {
+		deployment.RoleInstanceList.RoleInstance = append(deployment.RoleInstanceList.RoleInstance, &instances[i])
+	}
+
+	return deployment
+}
+
+func Test_roleInstanceWatcherUpdate(t *testing.T) {
+	events := make(chan RoleInstanceEvent, 10)
+	watcher := newTestRoleInstanceWatcher(events)
+
+	testCases := []struct {
+		instances []RoleInstance
+		expected  []RoleInstanceEvent
+	}{
+		{
+			[]RoleInstance{
+				{RoleName: "web", InstanceName: "web", InstanceStatus: "Provisioning", PowerState: "Starting"},
+			},
+			[]RoleInstanceEvent{
+				{RoleName: "web", InstanceName: "web", Status: "Provisioning", PowerState: "Starting"},
+			},
+		},
+		{
+			[]RoleInstance{
+				{RoleName: "web", InstanceName: "web", InstanceStatus: "Provisioning", PowerState: "Starting", InstanceStateDetails: "Installing extensions"},
+			},
+			[]RoleInstanceEvent{},
+		},
+		{
+			[]RoleInstance{
+				{RoleName: "web", InstanceName: "web", InstanceStatus: "Provisioning", PowerState: powerStateStarted},
+			},
+			[]RoleInstanceEvent{
+				{RoleName: "web", InstanceName: "web", PreviousStatus: "Provisioning", Status: "Provisioning", PreviousPowerState: "Starting", PowerState: powerStateStarted},
+			},
+		},
+		{
+			[]RoleInstance{
+				{RoleName: "web", InstanceName: "web", InstanceStatus: InstanceStatusReadyRole, PowerState: powerStateStarted},
+				{RoleName: "db", InstanceName: "db", InstanceStatus: "RoleStateUnknown", PowerState: "Starting"},
+			},
+			[]RoleInstanceEvent{
+				{RoleName: "web", InstanceName: "web", PreviousStatus: "Provisioning", Status: InstanceStatusReadyRole, PreviousPowerState: powerStateStarted, PowerState: powerStateStarted},
+				{RoleName: "db", InstanceName: "db", Status: "RoleStateUnknown", PowerState: "Starting"},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		watcher.update(newWatcherTestDeployment(testCase.instances...))
+
+		if len(events) != len(testCase.expected) {
+			t.Fatalf("Case %d: expected: %d events, got: %d", i, len(testCase.expected), len(events))
+		}
+		for j, expected := range testCase.expected {
+			event := <-events
+			expected.Instance = testCase.instances[j]
+			expected.Time = event.Time
+			if !reflect.DeepEqual(event, expected) {
+				t.Errorf("Case %d: expected: %+v, got: %+v", i, expected, event)
+			}
+		}
+	}
+}
+
+func Test_roleInstanceWatcherUpdateError(t *testing.T) {
+	events := make(chan RoleInstanceEvent, 10)
+	watcher := newTestRoleInstanceWatcher(events)
+
+	updateErr := errors.New("Code: InternalError, Message: The server encountered an internal error")
+	watcher.updateError(updateErr)
+
+	event := <-events
+	if event.Err != updateErr || len(event.InstanceName) > 0 {
+		t.Errorf("Expected: error event %s, got: %+v", updateErr, event)
+	}
+	if watcher.lastErr != updateErr {
+		t.Errorf("Expected: %s, got: %v", updateErr, watcher.lastErr)
+	}
+
+	watcher.update(nil)
+	if watcher.lastErr != updateErr {
+		t.Errorf("Expected: %s to be kept without a deployment, got: %v", updateErr, watcher.lastErr)
+	}
+
+	watcher.update(newWatcherTestDeployment())
+	if watcher.lastErr != nil {
+		t.Errorf("Expected: error cleared by a successful update, got: %s", watcher.lastErr)
+	}
+}
+
+func Test_WaitForReady(t *testing.T) {
+	watcher := newTestRoleInstanceWatcher(nil)
+	watcher.update(newWatcherTestDeployment(RoleInstance{RoleName: "web", InstanceName: "web", InstanceStatus: "Provisioning"}))
+
+	ready := make(chan error)
+	go func() {
+		instance, err := watcher.WaitForReady("web", time.Minute)
+		if err == nil && instance.InstanceStatus != InstanceStatusReadyRole {
+			err = errors.New("instance is " + instance.InstanceStatus)
+		}
+		ready <- err
+	}()
+
+	watcher.update(newWatcherTestDeployment(RoleInstance{RoleName: "web", InstanceName: "web", InstanceStatus: InstanceStatusReadyRole}))
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected: WaitForReady to return once the role is ready")
+	}
+}
+
+func Test_WaitForReadyFailedStatus(t *testing.T) {
+	for status := range failedInstanceStatuses {
+		watcher := newTestRoleInstanceWatcher(nil)
+		watcher.update(newWatcherTestDeployment(RoleInstance{RoleName: "web", InstanceName: "web_IN_0", InstanceStatus: status, InstanceErrorCode: "VMAgentFailed", InstanceStateDetails: "details"}))
+
+		_, err := watcher.WaitForReady("web", time.Minute)
+
+		expected := "Role instance web_IN_0 is in state " + status + ": VMAgentFailed details"
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected: %s, got: %v", expected, err)
+		}
+	}
+}
+
+func Test_WaitForReadyTimeout(t *testing.T) {
+	watcher := newTestRoleInstanceWatcher(nil)
+	watcher.update(newWatcherTestDeployment(RoleInstance{RoleName: "web", InstanceName: "web", InstanceStatus: "Provisioning"}))
+
+	_, err := watcher.WaitForReady("web", time.Millisecond)
+
+	expected := "Timed out waiting for role web to become ready, current status is Provisioning"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected: %s, got: %v", expected, err)
+	}
+
+	updateErr := errors.New("Code: InternalError, Message: The server encountered an internal error")
+	watcher.updateError(updateErr)
+
+	_, err = watcher.WaitForReady("web", time.Millisecond)
+	if err != updateErr {
+		t.Errorf("Expected: %s, got: %v", updateErr, err)
+	}
+}
+
+func Test_roleInstanceWatcherStop(t *testing.T) {
+	events := make(chan RoleInstanceEvent)
+	watcher := newTestRoleInstanceWatcher(events)
+
+	waiting := make(chan error)
+	go func() {
+		_, err := watcher.WaitForReady("web", time.Hour)
+		waiting <- err
+	}()
+
+	updating := make(chan struct{})
+	go func() {
+		// Nobody receives from events, sending blocks until the watcher is stopped
+		watcher.update(newWatcherTestDeployment(RoleInstance{RoleName: "db", InstanceName: "db", InstanceStatus: InstanceStatusReadyRole}))
+		close(updating)
+	}()
+
+	watcher.Stop()
+	watcher.Stop()
+
+	select {
+	case err := <-waiting:
+		if err == nil || err.Error() != watcherStoppedError {
+			t.Errorf("Expected: %s, got: %v", watcherStoppedError, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected: Stop to unblock WaitForReady")
+	}
+
+	select {
+	case <-updating:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected: Stop to unblock sending events")
+	}
+}
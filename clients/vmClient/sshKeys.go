@@ -0,0 +1,263 @@
+package vmClient
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+)
+
+const (
+	sshRsaKeyType           = "ssh-rsa"
+	sshCertValidityPeriod   = 10 * 365 * 24 * time.Hour
+	sshCertRsaKeySize       = 2048
+	defaultAuthorizedKeys   = "/home/%s/.ssh/authorized_keys"
+	defaultPrivateKeyPath   = "/home/%s/.ssh/id_rsa"
+	linuxProvisioningConfig = "LinuxProvisioningConfiguration"
+
+	invalidSshPublicKeyError  = "Invalid OpenSSH public key: %s"
+	unsupportedSshKeyError    = "Unsupported OpenSSH key type %s. Only %s keys are supported."
+	invalidSshPrivateKeyError = "File %s does not contain an RSA private key in PEM format."
+)
+
+//Region public methods starts
+
+func AddAzureVMSshPublicKeys(azureVMConfiguration *Role, path string, publicKeys ...string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "publicKeys")
+	}
+
+	provisioningConfig, err := getLinuxProvisioningConfig(azureVMConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) == 0 {
+		path = fmt.Sprintf(defaultAuthorizedKeys, provisioningConfig.UserName)
+	}
+
+	for _, publicKey := range publicKeys {
+		rsaPublicKey, err := parseSshRsaPublicKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		certData, err := createSshPublicKeyCertificate(rsaPublicKey, nil, azureVMConfiguration.RoleName)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceCertificate := ServiceCertificate{}
+		serviceCertificate.Xmlns = azureXmlns
+		serviceCertificate.Data = base64.StdEncoding.EncodeToString(certData)
+		serviceCertificate.CertificateFormat = "cer"
+
+		sshPublicKey := PublicKey{}
+		sshPublicKey.Fingerprint = fmt.Sprintf("%X", sha1.Sum(certData))
+		sshPublicKey.Path = path
+
+		provisioningConfig.SSH.PublicKeys.PublicKey = append(provisioningConfig.SSH.PublicKeys.PublicKey, sshPublicKey)
+		azureVMConfiguration.ServiceCertificates = append(azureVMConfiguration.ServiceCertificates, serviceCertificate)
+	}
+
+	return azureVMConfiguration, nil
+}
+
+func AddAzureVMSshKeyPair(azureVMConfiguration *Role, privateKeyPath, path string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(privateKeyPath) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "privateKeyPath")
+	}
+
+	provisioningConfig, err := getLinuxProvisioningConfig(azureVMConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) == 0 {
+		path = fmt.Sprintf(defaultPrivateKeyPath, provisioningConfig.UserName)
+	}
+
+	privateKey, err := readRsaPrivateKey(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	certData, err := createSshPublicKeyCertificate(&privateKey.PublicKey, privateKey, azureVMConfiguration.RoleName)
+	if err != nil {
+		return nil, err
+	}
+
+	pfxData, err := createPfx(certData, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceCertificate := ServiceCertificate{}
+	serviceCertificate.Xmlns = azureXmlns
+	serviceCertificate.Data = base64.StdEncoding.EncodeToString(pfxData)
+	serviceCertificate.CertificateFormat = "pfx"
+
+	keyPair := KeyPair{}
+	keyPair.Fingerprint = fmt.Sprintf("%X", sha1.Sum(certData))
+	keyPair.Path = path
+
+	provisioningConfig.SSH.KeyPairs.KeyPair = append(provisioningConfig.SSH.KeyPairs.KeyPair, keyPair)
+	azureVMConfiguration.ServiceCertificates = append(azureVMConfiguration.ServiceCertificates, serviceCertificate)
+
+	return azureVMConfiguration, nil
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func getLinuxProvisioningConfig(azureVMConfiguration *Role) (*ConfigurationSet, error) {
+	configurationSets := azureVMConfiguration.ConfigurationSets.ConfigurationSet
+	for i := 0; i < len(configurationSets); i++ {
+		if configurationSets[i].ConfigurationSetType != linuxProvisioningConfig {
+			continue
+		}
+
+		return &configurationSets[i], nil
+	}
+
+	return nil, errors.New(provisioningConfDoesNotExistsError)
+}
+
+func parseSshRsaPublicKey(publicKey string) (*rsa.PublicKey, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf(invalidSshPublicKeyError, publicKey)
+	}
+	if fields[0] != sshRsaKeyType {
+		return nil, fmt.Errorf(unsupportedSshKeyError, fields[0], sshRsaKeyType)
+	}
+
+	keyData, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf(invalidSshPublicKeyError, err)
+	}
+
+	keyType, keyData, err := readSshString(keyData)
+	if err != nil {
+		return nil, err
+	}
+	if string(keyType) != sshRsaKeyType {
+		return nil, fmt.Errorf(unsupportedSshKeyError, string(keyType), sshRsaKeyType)
+	}
+
+	exponent, keyData, err := readSshString(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	modulus, _, err := readSshString(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	e := new(big.Int).SetBytes(exponent)
+	if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) {
+		return nil, fmt.Errorf(invalidSshPublicKeyError, "exponent is too large")
+	}
+
+	rsaPublicKey := new(rsa.PublicKey)
+	rsaPublicKey.E = int(e.Int64())
+	rsaPublicKey.N = new(big.Int).SetBytes(modulus)
+
+	return rsaPublicKey, nil
+}
+
+func readSshString(data []byte) ([]byte, []byte, error) {
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf(invalidSshPublicKeyError, "unexpected end of key data")
+	}
+
+	length := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint32(len(data)) < length {
+		return nil, nil, fmt.Errorf(invalidSshPublicKeyError, "unexpected end of key data")
+	}
+
+	return data[:length], data[length:], nil
+}
+
+// createSshPublicKeyCertificate wraps an RSA public key into an X.509 certificate
+// so it can be uploaded as a service certificate. The Azure agent only uses the
+// public key of the certificate, so when the private key is not known the
+// certificate is signed with a throwaway key.
+func createSshPublicKeyCertificate(publicKey *rsa.PublicKey, signingKey *rsa.PrivateKey, commonName string) ([]byte, error) {
+	var err error
+	if signingKey == nil {
+		signingKey, err = rsa.GenerateKey(rand.Reader, sshCertRsaKeySize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(sshCertValidityPeriod),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	return x509.CreateCertificate(rand.Reader, &template, &template, publicKey, signingKey)
+}
+
+func readRsaPrivateKey(privateKeyPath string) (*rsa.PrivateKey, error) {
+	keyData, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(keyData)
+	if block == nil {
+		return nil, fmt.Errorf(invalidSshPrivateKeyError, privateKeyPath)
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err == nil {
+		return privateKey, nil
+	}
+
+	pkcs8Key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf(invalidSshPrivateKeyError, privateKeyPath)
+	}
+
+	privateKey, ok := pkcs8Key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf(invalidSshPrivateKeyError, privateKeyPath)
+	}
+
+	return privateKey, nil
+}
+
+//Region private methods ends
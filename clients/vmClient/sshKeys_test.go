@@ -0,0 +1,94 @@
+package vmClient
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func Test_parseSshRsaPublicKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := "ssh-rsa " + encodeTestSshRsaKey(&privateKey.PublicKey) + " user@host"
+	rsaPublicKey, err := parseSshRsaPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if rsaPublicKey.E != privateKey.PublicKey.E || rsaPublicKey.N.Cmp(privateKey.PublicKey.N) != 0 {
+		t.Errorf("Parsed key does not match the original key")
+	}
+
+	invalidKeys := []string{
+		"",
+		"ssh-rsa",
+		"ssh-dss AAAAB3NzaC1kc3MAAACBAP",
+		"ssh-rsa not-base64!",
+		"ssh-rsa " + base64.StdEncoding.EncodeToString([]byte{0, 0, 0, 7, 's', 's', 'h'}),
+		"ssh-rsa " + base64.StdEncoding.EncodeToString(append(encodeTestSshString([]byte("ssh-dss")), encodeTestSshString([]byte{1})...)),
+	}
+	for _, invalidKey := range invalidKeys {
+		if _, err := parseSshRsaPublicKey(invalidKey); err == nil {
+			t.Errorf("Expected an error for key '%s'", invalidKey)
+		}
+	}
+}
+
+func Test_AddAzureVMSshPublicKeys(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role := &Role{RoleName: "web1"}
+	role.ConfigurationSets.ConfigurationSet = []ConfigurationSet{{ConfigurationSetType: linuxProvisioningConfig, UserName: "azure"}}
+
+	_, err = AddAzureVMSshPublicKeys(role, "", "ssh-rsa "+encodeTestSshRsaKey(&privateKey.PublicKey))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	publicKeys := role.ConfigurationSets.ConfigurationSet[0].SSH.PublicKeys.PublicKey
+	if len(publicKeys) != 1 || len(role.ServiceCertificates) != 1 {
+		t.Fatalf("Expected one public key and one service certificate")
+	}
+	if expected := "/home/azure/.ssh/authorized_keys"; publicKeys[0].Path != expected {
+		t.Errorf("Wrong path. Expected: %s, got: %s", expected, publicKeys[0].Path)
+	}
+
+	certData, _ := base64.StdEncoding.DecodeString(role.ServiceCertificates[0].Data)
+	if fingerprint := fmt.Sprintf("%X", sha1.Sum(certData)); fingerprint != publicKeys[0].Fingerprint {
+		t.Errorf("Fingerprint %s does not match the certificate", publicKeys[0].Fingerprint)
+	}
+
+	cert, err := x509.ParseCertificate(certData)
+	if err != nil {
+		t.Fatalf("Service certificate is not valid: %s", err)
+	}
+	if cert.PublicKey.(*rsa.PublicKey).N.Cmp(privateKey.PublicKey.N) != 0 {
+		t.Errorf("Certificate does not hold the SSH public key")
+	}
+}
+
+func encodeTestSshRsaKey(publicKey *rsa.PublicKey) string {
+	keyData := encodeTestSshString([]byte(sshRsaKeyType))
+	keyData = append(keyData, encodeTestSshString(big.NewInt(int64(publicKey.E)).Bytes())...)
+	keyData = append(keyData, encodeTestSshString(append([]byte{0}, publicKey.N.Bytes()...))...)
+
+	return base64.StdEncoding.EncodeToString(keyData)
+}
+
+func encodeTestSshString(data []byte) []byte {
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(data)))
+
+	return append(length, data...)
+}
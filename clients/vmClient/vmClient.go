@@ -10,29 +10,51 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
 	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/affinityGroupClient"
 	"github.com/MSOpenTech/azure-sdk-for-go/clients/imageClient"
 	"github.com/MSOpenTech/azure-sdk-for-go/clients/locationClient"
 	"github.com/MSOpenTech/azure-sdk-for-go/clients/storageServiceClient"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/vnetClient"
 )
 
 const (
 	azureXmlns                        = "http://schemas.microsoft.com/windowsazure"
 	azureDeploymentListURL            = "services/hostedservices/%s/deployments"
 	azureHostedServiceListURL         = "services/hostedservices"
+	azureHostedServiceURL             = "services/hostedservices/%s"
+	azureHostedServiceDetailURL       = "services/hostedservices/%s?embed-detail=true"
 	deleteAzureHostedServiceURL       = "services/hostedservices/%s?comp=media"
 	azureHostedServiceAvailabilityURL = "services/hostedservices/operations/isavailable/%s"
 	azureDeploymentURL                = "services/hostedservices/%s/deployments/%s"
 	deleteAzureDeploymentURL          = "services/hostedservices/%s/deployments/%s?comp=media"
+	azureDeploymentSlotURL            = "services/hostedservices/%s/deploymentslots/%s"
+	deleteAzureDeploymentSlotURL      = "services/hostedservices/%s/deploymentslots/%s?comp=media"
+	azureRoleListURL                  = "services/hostedservices/%s/deployments/%s/roles"
 	azureRoleURL                      = "services/hostedservices/%s/deployments/%s/roles/%s"
+	azureDataDiskListURL              = "services/hostedservices/%s/deployments/%s/roles/%s/DataDisks"
+	azureDataDiskURL                  = "services/hostedservices/%s/deployments/%s/roles/%s/DataDisks/%d"
+	deleteAzureDataDiskURL            = "services/hostedservices/%s/deployments/%s/roles/%s/DataDisks/%d?comp=media"
 	azureOperationsURL                = "services/hostedservices/%s/deployments/%s/roleinstances/%s/Operations"
+	azureRolesOperationsURL           = "services/hostedservices/%s/deployments/%s/Roles/Operations"
 	azureCertificatListURL            = "services/hostedservices/%s/certificates"
 	azureRoleSizeListURL              = "rolesizes"
 
+	DeploymentSlotProduction = "Production"
+	DeploymentSlotStaging    = "Staging"
+
+	PostShutdownActionStopped            = "Stopped"
+	PostShutdownActionStoppedDeallocated = "StoppedDeallocated"
+
+	powerStateStarted       = "Started"
+	roleRestartTimeout      = 15 * time.Minute
+	roleRestartBeginTimeout = 3 * time.Minute
+
 	osLinux                   = "Linux"
 	osWindows                 = "Windows"
 	dockerPublicConfigVersion = 2
@@ -44,6 +66,11 @@ const (
 	invalidPasswordLengthError         = "Password must be between 4 and 30 characters."
 	invalidPasswordError               = "Password must have at least one upper case, lower case and numeric character."
 	invalidRoleSizeError               = "Invalid role size: %s. Available role sizes: %s"
+	invalidDeploymentSlotError         = "Invalid deployment slot: %s. Valid values are 'Production' and 'Staging'"
+	emptyHostedServiceUpdateError      = "You should specify a label or a description to update hosted service %s"
+	invalidPostShutdownActionError     = "Invalid post shutdown action: %s. Valid values are 'Stopped' and 'StoppedDeallocated'"
+	roleInstanceNotFoundError          = "Role instance %s was not found in deployment %s"
+	storageServiceLeftBehindError      = "%s (left behind storage service %s: %s)"
 )
 
 //Region public methods starts
@@ -59,43 +86,60 @@ func CreateAzureVM(azureVMConfiguration *Role, dnsName, location string) error {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "location")
 	}
 
-	err := verifyDNSname(dnsName)
-	if err != nil {
-		return err
-	}
+	return createAzureVM(azureVMConfiguration, dnsName, location, "")
+}
 
-	requestId, err := CreateHostedService(dnsName, location)
-	if err != nil {
-		return err
+func CreateAzureVMInAffinityGroup(azureVMConfiguration *Role, dnsName, affinityGroup string) error {
+	if azureVMConfiguration == nil {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(dnsName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
+	}
+	if len(affinityGroup) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "affinityGroup")
 	}
 
-	azure.WaitAsyncOperation(requestId)
+	return createAzureVM(azureVMConfiguration, dnsName, "", affinityGroup)
+}
 
-	if azureVMConfiguration.UseCertAuth {
-		err = uploadServiceCert(dnsName, azureVMConfiguration.CertPath)
+func AddAzureVMRole(cloudserviceName, deploymentName string, azureVMConfiguration *Role) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if azureVMConfiguration == nil {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+
+	if len(azureVMConfiguration.PendingStorageServiceName) > 0 {
+		err := createPendingStorageService(azureVMConfiguration.PendingStorageServiceName, azureVMConfiguration.PendingStorageServiceLocation)
 		if err != nil {
-			DeleteHostedService(dnsName)
 			return err
 		}
 	}
 
-	vMDeployment := createVMDeploymentConfig(azureVMConfiguration)
-	vMDeploymentBytes, err := xml.Marshal(vMDeployment)
+	err := uploadRoleCertificates(cloudserviceName, azureVMConfiguration)
 	if err != nil {
-		DeleteHostedService(dnsName)
 		return err
 	}
 
-	requestURL := fmt.Sprintf(azureDeploymentListURL, azureVMConfiguration.RoleName)
-	requestId, err = azure.SendAzurePostRequest(requestURL, vMDeploymentBytes)
+	var roleBytes bytes.Buffer
+	roleElement := xml.StartElement{Name: xml.Name{Space: azureXmlns, Local: "PersistentVMRole"}}
+	err = xml.NewEncoder(&roleBytes).EncodeElement(azureVMConfiguration, roleElement)
 	if err != nil {
-		DeleteHostedService(dnsName)
 		return err
 	}
 
-	azure.WaitAsyncOperation(requestId)
+	requestURL := fmt.Sprintf(azureRoleListURL, cloudserviceName, deploymentName)
+	requestId, azureErr := azure.SendAzurePostRequest(requestURL, roleBytes.Bytes())
+	if azureErr != nil {
+		return azureErr
+	}
 
-	return nil
+	return azure.WaitAsyncOperation(requestId)
 }
 
 func CreateHostedService(dnsName, location string) (string, error) {
@@ -106,37 +150,18 @@ func CreateHostedService(dnsName, location string) (string, error) {
 		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "location")
 	}
 
-	err := verifyDNSname(dnsName)
-	if err != nil {
-		return "", err
-	}
-
-	result, reason, err := CheckHostedServiceNameAvailability(dnsName)
-	if err != nil {
-		return "", err
-	}
-	if !result {
-		return "", fmt.Errorf("%s Hosted service name: %s", reason, dnsName)
-	}
-
-	err = locationClient.ResolveLocation(location)
-	if err != nil {
-		return "", err
-	}
+	return createHostedService(dnsName, location, "")
+}
 
-	hostedServiceDeployment := createHostedServiceDeploymentConfig(dnsName, location)
-	hostedServiceBytes, err := xml.Marshal(hostedServiceDeployment)
-	if err != nil {
-		return "", err
+func CreateHostedServiceInAffinityGroup(dnsName, affinityGroup string) (string, error) {
+	if len(dnsName) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
 	}
-
-	requestURL := azureHostedServiceListURL
-	requestId, err := azure.SendAzurePostRequest(requestURL, hostedServiceBytes)
-	if err != nil {
-		return "", err
+	if len(affinityGroup) == 0 {
+		return "", fmt.Errorf(azure.ParamNotSpecifiedError, "affinityGroup")
 	}
 
-	return requestId, nil
+	return createHostedService(dnsName, "", affinityGroup)
 }
 
 func CheckHostedServiceNameAvailability(dnsName string) (bool, string, error) {
@@ -184,6 +209,113 @@ func DeleteHostedService(dnsName string) error {
 	return nil
 }
 
+func ListHostedServices() (*HostedServiceList, error) {
+	hostedServiceList := new(HostedServiceList)
+
+	response, err := azure.SendAzureGetRequest(azureHostedServiceListURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, hostedServiceList)
+	if err != nil {
+		return nil, err
+	}
+
+	return hostedServiceList, nil
+}
+
+func GetHostedService(dnsName string, embedDetail bool) (*HostedService, error) {
+	if len(dnsName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
+	}
+
+	requestURL := fmt.Sprintf(azureHostedServiceURL, dnsName)
+	if embedDetail {
+		requestURL = fmt.Sprintf(azureHostedServiceDetailURL, dnsName)
+	}
+
+	response, err := azure.SendAzureGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	hostedService := new(HostedService)
+	err = xml.Unmarshal(response, hostedService)
+	if err != nil {
+		return nil, err
+	}
+
+	return hostedService, nil
+}
+
+func UpdateHostedService(dnsName, label, description string, extendedProperties []ExtendedProperty) error {
+	if len(dnsName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
+	}
+	if len(label) == 0 && len(description) == 0 {
+		return fmt.Errorf(emptyHostedServiceUpdateError, dnsName)
+	}
+
+	hostedServiceUpdate := createUpdateHostedServiceConfig(label, description, extendedProperties)
+	hostedServiceUpdateBytes, err := xml.Marshal(hostedServiceUpdate)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureHostedServiceURL, dnsName)
+	requestId, err := azure.SendAzurePutRequest(requestURL, hostedServiceUpdateBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func DeleteDeploymentSlot(dnsName, deploymentSlot string, deleteVHDs bool) error {
+	if len(dnsName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
+	}
+	if deploymentSlot != DeploymentSlotProduction && deploymentSlot != DeploymentSlotStaging {
+		return fmt.Errorf(invalidDeploymentSlotError, deploymentSlot)
+	}
+
+	requestURL := fmt.Sprintf(azureDeploymentSlotURL, dnsName, deploymentSlot)
+	if deleteVHDs {
+		requestURL = fmt.Sprintf(deleteAzureDeploymentSlotURL, dnsName, deploymentSlot)
+	}
+
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func ListOrphanedHostedServices() ([]HostedService, error) {
+	hostedServiceList, err := ListHostedServices()
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedServices := []HostedService{}
+	for _, hostedService := range hostedServiceList.HostedServices {
+		hostedServiceDetails, err := GetHostedService(hostedService.ServiceName, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(hostedServiceDetails.Deployments) > 0 {
+			continue
+		}
+
+		orphanedServices = append(orphanedServices, *hostedServiceDetails)
+	}
+
+	return orphanedServices, nil
+}
+
 func CreateAzureVMConfiguration(dnsName, instanceSize, imageName, location string) (*Role, error) {
 	if len(dnsName) == 0 {
 		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
@@ -213,6 +345,11 @@ func CreateAzureVMConfiguration(dnsName, instanceSize, imageName, location strin
 		return nil, err
 	}
 
+	err = locationClient.ResolveVMRoleSize(location, instanceSize)
+	if err != nil {
+		return nil, err
+	}
+
 	role, err := createAzureVMRole(dnsName, instanceSize, imageName, location)
 	if err != nil {
 		return nil, err
@@ -221,6 +358,51 @@ func CreateAzureVMConfiguration(dnsName, instanceSize, imageName, location strin
 	return role, nil
 }
 
+// CreateAzureVMConfigurationFromVMImage creates a role from a captured VM
+// image. The OS disk and every data disk of the image get their own VHD in a
+// storage service in location.
+func CreateAzureVMConfigurationFromVMImage(dnsName, instanceSize, vmImageName, location string) (*Role, error) {
+	if len(dnsName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
+	}
+	if len(instanceSize) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "instanceSize")
+	}
+	if len(vmImageName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "vmImageName")
+	}
+	if len(location) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "location")
+	}
+
+	err := verifyDNSname(dnsName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = locationClient.ResolveLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ResolveRoleSize(instanceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	err = locationClient.ResolveVMRoleSize(location, instanceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	vmImage, err := imageClient.GetVMImage(vmImageName)
+	if err != nil {
+		return nil, err
+	}
+
+	return createAzureVMRoleFromVMImage(dnsName, instanceSize, vmImage, location)
+}
+
 func AddAzureLinuxProvisioningConfig(azureVMConfiguration *Role, userName, password, certPath string, sshPort int) (*Role, error) {
 	if azureVMConfiguration == nil {
 		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
@@ -254,6 +436,59 @@ func AddAzureLinuxProvisioningConfig(azureVMConfiguration *Role, userName, passw
 	return azureVMConfiguration, nil
 }
 
+func SetAzureVMAvailabilitySet(azureVMConfiguration *Role, availabilitySetName string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(availabilitySetName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "availabilitySetName")
+	}
+
+	azureVMConfiguration.AvailabilitySetName = availabilitySetName
+	return azureVMConfiguration, nil
+}
+
+func SetAzureVMVirtualNetwork(azureVMConfiguration *Role, virtualNetworkName string, subnetNames []string, staticIPAddress string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(virtualNetworkName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "virtualNetworkName")
+	}
+	if len(subnetNames) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "subnetNames")
+	}
+
+	var err error
+	if len(staticIPAddress) == 0 {
+		err = vnetClient.ResolveSubnets(virtualNetworkName, subnetNames)
+	} else {
+		err = vnetClient.ResolveStaticIPAddress(virtualNetworkName, subnetNames, staticIPAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	networkConfig := getNetworkConfigurationSet(azureVMConfiguration)
+	networkConfig.SubnetNames = subnetNames
+	networkConfig.StaticVirtualNetworkIPAddress = staticIPAddress
+	azureVMConfiguration.VirtualNetworkName = virtualNetworkName
+
+	return azureVMConfiguration, nil
+}
+
+func SetAzureVMReservedIP(azureVMConfiguration *Role, reservedIPName string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(reservedIPName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "reservedIPName")
+	}
+
+	azureVMConfiguration.ReservedIPName = reservedIPName
+	return azureVMConfiguration, nil
+}
+
 func SetAzureVMExtension(azureVMConfiguration *Role, name string, publisher string, version string, referenceName string, state string, publicConfigurationValue string, privateConfigurationValue string) (*Role, error) {
 	if azureVMConfiguration == nil {
 		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
@@ -301,29 +536,43 @@ func SetAzureVMExtension(azureVMConfiguration *Role, name string, publisher stri
 	return azureVMConfiguration, nil
 }
 
+// SetAzureDockerVMExtension enables the Docker extension without TLS, so the
+// daemon port accepts unauthenticated connections. Use
+// SetAzureSecureDockerVMExtension for VMs reachable from the internet.
 func SetAzureDockerVMExtension(azureVMConfiguration *Role, dockerPort int, version string) (*Role, error) {
 	if azureVMConfiguration == nil {
 		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
 	}
 
-	if len(version) == 0 {
-		version = "0.3"
+	return setAzureDockerVMExtension(azureVMConfiguration, dockerPort, version, "{}")
+}
+
+// SetAzureSecureDockerVMExtension enables the Docker extension with TLS client
+// authentication. The server certificate is issued for the cloud service
+// dnsName, and the client certificates are written to dockerCertDir, which can
+// be used as DOCKER_CERT_PATH.
+func SetAzureSecureDockerVMExtension(azureVMConfiguration *Role, dnsName string, dockerPort int, version, dockerCertDir string) (*Role, error) {
+	if azureVMConfiguration == nil {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "azureVMConfiguration")
+	}
+	if len(dnsName) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "dnsName")
+	}
+	if len(dockerCertDir) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "dockerCertDir")
 	}
 
-	err := addDockerPort(azureVMConfiguration.ConfigurationSets.ConfigurationSet, dockerPort)
+	certificates, err := GenerateDockerCertificates(dockerCertDir, getDockerHosts(dnsName))
 	if err != nil {
 		return nil, err
 	}
 
-	publicConfiguration, err := createDockerPublicConfig(dockerPort)
+	privateConfiguration, err := createDockerPrivateConfig(certificates)
 	if err != nil {
 		return nil, err
 	}
 
-	privateConfiguration := "{}"
-
-	azureVMConfiguration, err = SetAzureVMExtension(azureVMConfiguration, "DockerExtension", "MSOpenTech.Extensions", version, "DockerExtension", "enable", publicConfiguration, privateConfiguration)
-	return azureVMConfiguration, nil
+	return setAzureDockerVMExtension(azureVMConfiguration, dockerPort, version, privateConfiguration)
 }
 
 func GetVMDeployment(cloudserviceName, deploymentName string) (*VMDeployment, error) {
@@ -396,46 +645,144 @@ func GetRole(cloudserviceName, deploymentName, roleName string) (*Role, error) {
 	return role, nil
 }
 
-func StartRole(cloudserviceName, deploymentName, roleName string) error {
+func UpdateRole(cloudserviceName, deploymentName string, role *Role) error {
 	if len(cloudserviceName) == 0 {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
 	}
 	if len(deploymentName) == 0 {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
 	}
-	if len(roleName) == 0 {
-		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	if role == nil {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "role")
 	}
 
-	startRoleOperation := createStartRoleOperation()
+	requestURL := fmt.Sprintf(azureRoleURL, cloudserviceName, deploymentName, role.RoleName)
+	currentRoleBytes, err := azure.SendAzureGetRequest(requestURL)
+	if err != nil {
+		return err
+	}
 
-	startRoleOperationBytes, err := xml.Marshal(startRoleOperation)
+	currentRole := new(Role)
+	err = xml.Unmarshal(currentRoleBytes, currentRole)
 	if err != nil {
 		return err
 	}
 
-	requestURL := fmt.Sprintf(azureOperationsURL, cloudserviceName, deploymentName, roleName)
-	requestId, azureErr := azure.SendAzurePostRequest(requestURL, startRoleOperationBytes)
-	if azureErr != nil {
-		return azureErr
+	updatedRole := *role
+	if len(updatedRole.RoleSize) == 0 {
+		updatedRole.RoleSize = currentRole.RoleSize
 	}
+	resized := updatedRole.RoleSize != currentRole.RoleSize
+	if resized {
+		err = ResolveRoleSize(updatedRole.RoleSize)
+		if err != nil {
+			return err
+		}
 
-	azure.WaitAsyncOperation(requestId)
-	return nil
-}
+		location, err := getHostedServiceLocation(cloudserviceName)
+		if err != nil {
+			return err
+		}
 
-func ShutdownRole(cloudserviceName, deploymentName, roleName string) error {
-	if len(cloudserviceName) == 0 {
-		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
-	}
-	if len(deploymentName) == 0 {
-		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
-	}
-	if len(roleName) == 0 {
-		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+		err = locationClient.ResolveVMRoleSize(location, updatedRole.RoleSize)
+		if err != nil {
+			return err
+		}
 	}
 
-	shutdownRoleOperation := createShutdowRoleOperation()
+	roleInstance, err := getRoleInstance(cloudserviceName, deploymentName, role.RoleName)
+	if err != nil {
+		return err
+	}
+
+	var roleBytes bytes.Buffer
+	roleElement := xml.StartElement{Name: xml.Name{Space: azureXmlns, Local: "PersistentVMRole"}}
+	err = xml.NewEncoder(&roleBytes).EncodeElement(&updatedRole, roleElement)
+	if err != nil {
+		return err
+	}
+
+	updatedRoleBytes, err := mergeXml(currentRoleBytes, roleBytes.Bytes())
+	if err != nil {
+		return err
+	}
+
+	requestId, azureErr := azure.SendAzurePutRequest(requestURL, updatedRoleBytes)
+	if azureErr != nil {
+		return azureErr
+	}
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return err
+	}
+
+	// Resizing a running VM restarts it, wait until it has gone down and is back
+	if roleInstance.PowerState == powerStateStarted && resized {
+		err = waitForRoleRestartBegin(cloudserviceName, deploymentName, role.RoleName, roleRestartBeginTimeout)
+		if err != nil {
+			return err
+		}
+
+		_, err = WaitForRoleReady(cloudserviceName, deploymentName, role.RoleName, roleRestartTimeout)
+		return err
+	}
+
+	return nil
+}
+
+func StartRole(cloudserviceName, deploymentName, roleName string) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(roleName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	}
+
+	startRoleOperation := createStartRoleOperation()
+
+	startRoleOperationBytes, err := xml.Marshal(startRoleOperation)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureOperationsURL, cloudserviceName, deploymentName, roleName)
+	requestId, azureErr := azure.SendAzurePostRequest(requestURL, startRoleOperationBytes)
+	if azureErr != nil {
+		return azureErr
+	}
+
+	azure.WaitAsyncOperation(requestId)
+	return nil
+}
+
+func ShutdownRole(cloudserviceName, deploymentName, roleName string) error {
+	return ShutdownRoleWithPostShutdownAction(cloudserviceName, deploymentName, roleName, "")
+}
+
+// ShutdownRoleWithPostShutdownAction shuts the role down and leaves it in the
+// given state. StoppedDeallocated releases the compute resources so the role is
+// no longer billed. An empty action keeps the service default, Stopped.
+func ShutdownRoleWithPostShutdownAction(cloudserviceName, deploymentName, roleName, postShutdownAction string) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(roleName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	}
+
+	err := verifyPostShutdownAction(postShutdownAction)
+	if err != nil {
+		return err
+	}
+
+	shutdownRoleOperation := createShutdowRoleOperation(postShutdownAction)
 
 	shutdownRoleOperationBytes, err := xml.Marshal(shutdownRoleOperation)
 	if err != nil {
@@ -452,6 +799,65 @@ func ShutdownRole(cloudserviceName, deploymentName, roleName string) error {
 	return nil
 }
 
+func StartRoles(cloudserviceName, deploymentName string, roleNames []string) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(roleNames) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleNames")
+	}
+
+	startRolesOperation := createStartRolesOperation(roleNames)
+
+	startRolesOperationBytes, err := xml.Marshal(startRolesOperation)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureRolesOperationsURL, cloudserviceName, deploymentName)
+	requestId, azureErr := azure.SendAzurePostRequest(requestURL, startRolesOperationBytes)
+	if azureErr != nil {
+		return azureErr
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func ShutdownRoles(cloudserviceName, deploymentName string, roleNames []string, postShutdownAction string) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(roleNames) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleNames")
+	}
+
+	err := verifyPostShutdownAction(postShutdownAction)
+	if err != nil {
+		return err
+	}
+
+	shutdownRolesOperation := createShutdownRolesOperation(roleNames, postShutdownAction)
+
+	shutdownRolesOperationBytes, err := xml.Marshal(shutdownRolesOperation)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureRolesOperationsURL, cloudserviceName, deploymentName)
+	requestId, azureErr := azure.SendAzurePostRequest(requestURL, shutdownRolesOperationBytes)
+	if azureErr != nil {
+		return azureErr
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
 func RestartRole(cloudserviceName, deploymentName, roleName string) error {
 	if len(cloudserviceName) == 0 {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
@@ -501,20 +907,70 @@ func DeleteRole(cloudserviceName, deploymentName, roleName string) error {
 	return nil
 }
 
-func GetRoleSizeList() (RoleSizeList, error) {
-	roleSizeList := RoleSizeList{}
+// AddDataDisk attaches a data disk to a role. A new disk without a MediaLink is
+// placed by the disk placement policy in the location of the cloud service.
+func AddDataDisk(cloudserviceName, deploymentName, roleName string, dataDisk DataVirtualHardDisk) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(roleName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	}
 
-	response, err := azure.SendAzureGetRequest(azureRoleSizeListURL)
-	if err != nil {
-		return roleSizeList, err
+	createdStorageServiceName := ""
+	if len(dataDisk.MediaLink) == 0 && len(dataDisk.DiskName) == 0 {
+		location, err := getHostedServiceLocation(cloudserviceName)
+		if err != nil {
+			return err
+		}
+
+		dataDisk.MediaLink, createdStorageServiceName, err = getVHDMediaLink(roleName+"-lun"+strconv.Itoa(dataDisk.Lun), location)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = xml.Unmarshal(response, &roleSizeList)
-	if err != nil {
-		return roleSizeList, err
+	err := addDataDisk(cloudserviceName, deploymentName, roleName, dataDisk)
+	if err != nil && len(createdStorageServiceName) > 0 {
+		// The storage service was created for this disk only
+		deleteErr := storageServiceClient.DeleteStorageService(createdStorageServiceName)
+		if deleteErr != nil {
+			return fmt.Errorf(storageServiceLeftBehindError, err, createdStorageServiceName, deleteErr)
+		}
 	}
 
-	return roleSizeList, err
+	return err
+}
+
+func DeleteDataDisk(cloudserviceName, deploymentName, roleName string, lun int, deleteVHD bool) error {
+	if len(cloudserviceName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "cloudserviceName")
+	}
+	if len(deploymentName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "deploymentName")
+	}
+	if len(roleName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleName")
+	}
+
+	requestURL := fmt.Sprintf(azureDataDiskURL, cloudserviceName, deploymentName, roleName, lun)
+	if deleteVHD {
+		requestURL = fmt.Sprintf(deleteAzureDataDiskURL, cloudserviceName, deploymentName, roleName, lun)
+	}
+
+	requestId, azureErr := azure.SendAzureDeleteRequest(requestURL)
+	if azureErr != nil {
+		return azureErr
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func GetRoleSizeList() (RoleSizeList, error) {
+	return getRoleSizeList(false)
 }
 
 func ResolveRoleSize(roleSizeName string) error {
@@ -522,7 +978,7 @@ func ResolveRoleSize(roleSizeName string) error {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "roleSizeName")
 	}
 
-	roleSizeList, err := GetRoleSizeList()
+	roleSizeList, err := getRoleSizeList(true)
 	if err != nil {
 		return err
 	}
@@ -547,6 +1003,193 @@ func ResolveRoleSize(roleSizeName string) error {
 
 //Region private methods starts
 
+func getRoleSizeList(cached bool) (RoleSizeList, error) {
+	roleSizeList := RoleSizeList{}
+
+	response, err := azure.SendAzureGetRequestWithCache(azureRoleSizeListURL, cached)
+	if err != nil {
+		return roleSizeList, err
+	}
+
+	err = xml.Unmarshal(response, &roleSizeList)
+	if err != nil {
+		return roleSizeList, err
+	}
+
+	return roleSizeList, nil
+}
+
+// waitForRoleRestartBegin waits until the role instance leaves ReadyRole. When it
+// is still ready after timeout the restart is assumed to have completed between
+// two polls.
+func waitForRoleRestartBegin(cloudserviceName, deploymentName, roleName string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		roleInstance, err := getRoleInstance(cloudserviceName, deploymentName, roleName)
+		if err != nil {
+			return err
+		}
+		if roleInstance.InstanceStatus != InstanceStatusReadyRole {
+			return nil
+		}
+
+		time.Sleep(defaultWatcherPollInterval)
+	}
+
+	return nil
+}
+
+func getRoleInstance(cloudserviceName, deploymentName, roleName string) (*RoleInstance, error) {
+	deployment, err := GetVMDeployment(cloudserviceName, deploymentName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, roleInstance := range deployment.RoleInstanceList.RoleInstance {
+		if roleInstance.RoleName != roleName {
+			continue
+		}
+
+		return roleInstance, nil
+	}
+
+	return nil, fmt.Errorf(roleInstanceNotFoundError, roleName, deploymentName)
+}
+
+func addDataDisk(cloudserviceName, deploymentName, roleName string, dataDisk DataVirtualHardDisk) error {
+	dataDisk.Xmlns = azureXmlns
+	dataDiskBytes, err := xml.Marshal(dataDisk)
+	if err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf(azureDataDiskListURL, cloudserviceName, deploymentName, roleName)
+	requestId, azureErr := azure.SendAzurePostRequest(requestURL, dataDiskBytes)
+	if azureErr != nil {
+		return azureErr
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func createAzureVM(azureVMConfiguration *Role, dnsName, location, affinityGroup string) error {
+	err := verifyDNSname(dnsName)
+	if err != nil {
+		return err
+	}
+
+	transaction := newVMCreationTransaction()
+	storageServiceName := azureVMConfiguration.PendingStorageServiceName
+	if len(storageServiceName) > 0 {
+		err = createPendingStorageService(storageServiceName, azureVMConfiguration.PendingStorageServiceLocation)
+		if err != nil {
+			return transaction.rollback(err)
+		}
+
+		transaction.record(CreatedResourceStorageService, storageServiceName, func() error {
+			return storageServiceClient.DeleteStorageService(storageServiceName)
+		})
+	}
+
+	requestId, err := createHostedService(dnsName, location, affinityGroup)
+	if err != nil {
+		return transaction.rollback(err)
+	}
+
+	transaction.record(CreatedResourceHostedService, dnsName, func() error {
+		return deleteHostedServiceIfExists(dnsName)
+	})
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return transaction.rollback(err)
+	}
+
+	err = uploadRoleCertificates(dnsName, azureVMConfiguration)
+	recordServiceCertificates(transaction, dnsName)
+	if err != nil {
+		return transaction.rollback(err)
+	}
+
+	vMDeployment := createVMDeploymentConfig(azureVMConfiguration)
+	vMDeploymentBytes, err := xml.Marshal(vMDeployment)
+	if err != nil {
+		return transaction.rollback(err)
+	}
+
+	requestURL := fmt.Sprintf(azureDeploymentListURL, dnsName)
+	requestId, err = azure.SendAzurePostRequest(requestURL, vMDeploymentBytes)
+	if err != nil {
+		return transaction.rollback(err)
+	}
+
+	recordRoleDisks(transaction, azureVMConfiguration)
+	transaction.record(CreatedResourceDeployment, vMDeployment.Name, func() error {
+		return deleteDeploymentIfExists(dnsName, vMDeployment.Name)
+	})
+
+	err = azure.WaitAsyncOperation(requestId)
+	if err != nil {
+		return transaction.rollback(err)
+	}
+
+	return nil
+}
+
+func createHostedService(dnsName, location, affinityGroup string) (string, error) {
+	err := verifyDNSname(dnsName)
+	if err != nil {
+		return "", err
+	}
+
+	result, reason, err := CheckHostedServiceNameAvailability(dnsName)
+	if err != nil {
+		return "", err
+	}
+	if !result {
+		return "", fmt.Errorf("%s Hosted service name: %s", reason, dnsName)
+	}
+
+	if len(location) > 0 {
+		err = locationClient.ResolveLocation(location)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	hostedServiceDeployment := createHostedServiceDeploymentConfig(dnsName, location, affinityGroup)
+	hostedServiceBytes, err := xml.Marshal(hostedServiceDeployment)
+	if err != nil {
+		return "", err
+	}
+
+	requestURL := azureHostedServiceListURL
+	requestId, err := azure.SendAzurePostRequest(requestURL, hostedServiceBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return requestId, nil
+}
+
+func uploadRoleCertificates(dnsName string, azureVMConfiguration *Role) error {
+	if azureVMConfiguration.UseCertAuth {
+		err := uploadServiceCert(dnsName, azureVMConfiguration.CertPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, serviceCertificate := range azureVMConfiguration.ServiceCertificates {
+		err := uploadServiceCertificate(dnsName, serviceCertificate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func createStartRoleOperation() StartRoleOperation {
 	startRoleOperation := StartRoleOperation{}
 	startRoleOperation.OperationType = "StartRoleOperation"
@@ -555,14 +1198,42 @@ func createStartRoleOperation() StartRoleOperation {
 	return startRoleOperation
 }
 
-func createShutdowRoleOperation() ShutdownRoleOperation {
+func createShutdowRoleOperation(postShutdownAction string) ShutdownRoleOperation {
 	shutdownRoleOperation := ShutdownRoleOperation{}
 	shutdownRoleOperation.OperationType = "ShutdownRoleOperation"
+	shutdownRoleOperation.PostShutdownAction = postShutdownAction
 	shutdownRoleOperation.Xmlns = azureXmlns
 
 	return shutdownRoleOperation
 }
 
+func createStartRolesOperation(roleNames []string) StartRolesOperation {
+	startRolesOperation := StartRolesOperation{}
+	startRolesOperation.OperationType = "StartRolesOperation"
+	startRolesOperation.Roles.Name = roleNames
+	startRolesOperation.Xmlns = azureXmlns
+
+	return startRolesOperation
+}
+
+func createShutdownRolesOperation(roleNames []string, postShutdownAction string) ShutdownRolesOperation {
+	shutdownRolesOperation := ShutdownRolesOperation{}
+	shutdownRolesOperation.OperationType = "ShutdownRolesOperation"
+	shutdownRolesOperation.Roles.Name = roleNames
+	shutdownRolesOperation.PostShutdownAction = postShutdownAction
+	shutdownRolesOperation.Xmlns = azureXmlns
+
+	return shutdownRolesOperation
+}
+
+func verifyPostShutdownAction(postShutdownAction string) error {
+	if len(postShutdownAction) == 0 || postShutdownAction == PostShutdownActionStopped || postShutdownAction == PostShutdownActionStoppedDeallocated {
+		return nil
+	}
+
+	return fmt.Errorf(invalidPostShutdownActionError, postShutdownAction)
+}
+
 func createRestartRoleOperation() RestartRoleOperation {
 	startRoleOperation := RestartRoleOperation{}
 	startRoleOperation.OperationType = "RestartRoleOperation"
@@ -581,6 +1252,34 @@ func createDockerPublicConfig(dockerPort int) (string, error) {
 	return string(configJson), nil
 }
 
+func setAzureDockerVMExtension(azureVMConfiguration *Role, dockerPort int, version, privateConfiguration string) (*Role, error) {
+	if len(version) == 0 {
+		version = dockerExtensionDefaultVersion
+	}
+
+	err := addDockerPort(azureVMConfiguration.ConfigurationSets.ConfigurationSet, dockerPort)
+	if err != nil {
+		return nil, err
+	}
+
+	publicConfiguration, err := createDockerPublicConfig(dockerPort)
+	if err != nil {
+		return nil, err
+	}
+
+	return SetAzureVMExtension(azureVMConfiguration, dockerExtensionName, dockerExtensionPublisher, version, dockerExtensionName, "enable", publicConfiguration, privateConfiguration)
+}
+
+// getDockerHosts returns the names the server certificate is valid for. A DNS
+// name without a domain is a cloud service name under cloudapp.net.
+func getDockerHosts(dnsName string) []string {
+	if strings.Contains(dnsName, ".") {
+		return []string{dnsName}
+	}
+
+	return []string{dnsName + cloudServiceDomainSuffix}
+}
+
 func addDockerPort(configurationSets []ConfigurationSet, dockerPort int) error {
 	if len(configurationSets) == 0 {
 		return errors.New(provisioningConfDoesNotExistsError)
@@ -598,17 +1297,33 @@ func addDockerPort(configurationSets []ConfigurationSet, dockerPort int) error {
 	return nil
 }
 
-func createHostedServiceDeploymentConfig(dnsName, location string) HostedServiceDeployment {
+func createHostedServiceDeploymentConfig(dnsName, location, affinityGroup string) HostedServiceDeployment {
 	deployment := HostedServiceDeployment{}
 	deployment.ServiceName = dnsName
 	label := base64.StdEncoding.EncodeToString([]byte(dnsName))
 	deployment.Label = label
 	deployment.Location = location
+	deployment.AffinityGroup = affinityGroup
 	deployment.Xmlns = azureXmlns
 
 	return deployment
 }
 
+func createUpdateHostedServiceConfig(label, description string, extendedProperties []ExtendedProperty) HostedServiceUpdate {
+	hostedServiceUpdate := HostedServiceUpdate{}
+	hostedServiceUpdate.Xmlns = azureXmlns
+	if len(label) > 0 {
+		hostedServiceUpdate.Label = base64.StdEncoding.EncodeToString([]byte(label))
+	}
+	hostedServiceUpdate.Description = description
+
+	if len(extendedProperties) > 0 {
+		hostedServiceUpdate.ExtendedProperties = &ExtendedPropertyList{ExtendedProperty: extendedProperties}
+	}
+
+	return hostedServiceUpdate
+}
+
 func createVMDeploymentConfig(role *Role) VMDeployment {
 	deployment := VMDeployment{}
 	deployment.Name = role.RoleName
@@ -616,6 +1331,8 @@ func createVMDeploymentConfig(role *Role) VMDeployment {
 	deployment.DeploymentSlot = "Production"
 	deployment.Label = role.RoleName
 	deployment.RoleList.Role = append(deployment.RoleList.Role, role)
+	deployment.VirtualNetworkName = role.VirtualNetworkName
+	deployment.ReservedIPName = role.ReservedIPName
 
 	return deployment
 }
@@ -627,59 +1344,139 @@ func createAzureVMRole(name, instanceSize, imageName, location string) (*Role, e
 	config.RoleType = "PersistentVMRole"
 	config.ProvisionGuestAgent = true
 	var err error
-	config.OSVirtualHardDisk, err = createOSVirtualHardDisk(name, imageName, location)
+	config.OSVirtualHardDisk, config.PendingStorageServiceName, err = createOSVirtualHardDisk(name, imageName, location)
 	if err != nil {
 		return nil, err
 	}
+	config.PendingStorageServiceLocation = location
 
 	return config, nil
 }
 
-func createOSVirtualHardDisk(dnsName, imageName, location string) (OSVirtualHardDisk, error) {
+func createAzureVMRoleFromVMImage(name, instanceSize string, vmImage *imageClient.VMImage, location string) (*Role, error) {
+	config := new(Role)
+	config.RoleName = name
+	config.RoleSize = instanceSize
+	config.RoleType = "PersistentVMRole"
+	config.ProvisionGuestAgent = true
+	config.VMImageName = vmImage.Name
+
+	var err error
+	config.OSVirtualHardDisk.OS = vmImage.OSDiskConfiguration.OS
+	config.OSVirtualHardDisk.MediaLink, config.PendingStorageServiceName, err = createVHDMediaLink(name, location, "")
+	if err != nil {
+		return nil, err
+	}
+	config.PendingStorageServiceLocation = location
+
+	for _, dataDiskConfiguration := range vmImage.DataDiskConfigurations {
+		dataDisk := DataVirtualHardDisk{}
+		dataDisk.Lun = dataDiskConfiguration.Lun
+		dataDisk.MediaLink, config.PendingStorageServiceName, err = createVHDMediaLink(name+"-lun"+strconv.Itoa(dataDiskConfiguration.Lun), location, config.PendingStorageServiceName)
+		if err != nil {
+			return nil, err
+		}
+
+		config.DataVirtualHardDisks.DataVirtualHardDisk = append(config.DataVirtualHardDisks.DataVirtualHardDisk, dataDisk)
+	}
+
+	return config, nil
+}
+
+func createOSVirtualHardDisk(dnsName, imageName, location string) (OSVirtualHardDisk, string, error) {
 	oSVirtualHardDisk := OSVirtualHardDisk{}
 
-	err := imageClient.ResolveImageName(imageName)
+	sourceImageName, err := imageClient.ResolveImage(imageName, location)
+	if err != nil {
+		return oSVirtualHardDisk, "", err
+	}
+
+	oSVirtualHardDisk.SourceImageName = sourceImageName
+	mediaLink, pendingStorageServiceName, err := createVHDMediaLink(dnsName, location, "")
 	if err != nil {
-		return oSVirtualHardDisk, err
+		return oSVirtualHardDisk, "", err
 	}
+	oSVirtualHardDisk.MediaLink = mediaLink
+
+	return oSVirtualHardDisk, pendingStorageServiceName, nil
+}
 
-	oSVirtualHardDisk.SourceImageName = imageName
-	oSVirtualHardDisk.MediaLink, err = getVHDMediaLink(dnsName, location)
+// getVHDMediaLink places a VHD for a disk added to an existing VM, so a
+// storage service it needs is created right away. Its name is returned.
+func getVHDMediaLink(dnsName, location string) (string, string, error) {
+	vhdMediaLink, pendingStorageServiceName, err := createVHDMediaLink(dnsName, location, "")
 	if err != nil {
-		return oSVirtualHardDisk, err
+		return "", "", err
 	}
 
-	return oSVirtualHardDisk, nil
+	if len(pendingStorageServiceName) > 0 {
+		err = createPendingStorageService(pendingStorageServiceName, location)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return vhdMediaLink, pendingStorageServiceName, nil
 }
 
-func getVHDMediaLink(dnsName, location string) (string, error) {
+// getHostedServiceLocation returns the location of the cloud service, or of
+// its affinity group.
+func getHostedServiceLocation(dnsName string) (string, error) {
+	hostedService, err := GetHostedService(dnsName, false)
+	if err != nil {
+		return "", err
+	}
+
+	properties := hostedService.HostedServiceProperties
+	if len(properties.Location) > 0 || len(properties.AffinityGroup) == 0 {
+		return properties.Location, nil
+	}
 
-	storageService, err := storageServiceClient.GetStorageServiceByLocation(location)
+	affinityGroup, err := affinityGroupClient.GetAffinityGroup(properties.AffinityGroup)
 	if err != nil {
 		return "", err
 	}
 
-	if storageService == nil {
+	return affinityGroup.Location, nil
+}
 
-		uuid, err := azure.NewUUID()
+// createVHDMediaLink places a new VHD according to the disk placement policy.
+// When no storage service qualifies, the VHD goes to pendingStorageServiceName
+// or to a newly named one. The storage service is not created here, its name is
+// returned so the caller can create it once the VM is actually deployed.
+func createVHDMediaLink(dnsName, location, pendingStorageServiceName string) (string, string, error) {
+	policy := GetDiskPlacementPolicy()
+
+	storageService, err := selectPlacementStorageService(policy, location)
+	if err != nil {
+		return "", "", err
+	}
+
+	if storageService != nil {
+		blobEndpoint, err := storageServiceClient.GetBlobEndpoint(storageService)
 		if err != nil {
-			return "", err
+			return "", "", err
+		}
+
+		vhdMediaLink := blobEndpoint + policy.Container + "/" + createVHDBlobName(policy, dnsName)
+		return vhdMediaLink, pendingStorageServiceName, nil
+	}
+
+	if len(pendingStorageServiceName) == 0 {
+		if policy.DisableStorageServiceCreation {
+			return "", "", fmt.Errorf(noPlacementStorageServiceError, location)
 		}
 
-		serviceName := "portalvhds" + uuid
-		storageService, err = storageServiceClient.CreateStorageService(serviceName, location)
+		uuid, err := azure.NewUUID()
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
-	}
 
-	blobEndpoint, err := storageServiceClient.GetBlobEndpoint(storageService)
-	if err != nil {
-		return "", err
+		pendingStorageServiceName = "portalvhds" + uuid
 	}
 
-	vhdMediaLink := blobEndpoint + "vhds/" + dnsName + "-" + time.Now().Local().Format("20060102150405") + ".vhd"
-	return vhdMediaLink, nil
+	vhdMediaLink := getPendingBlobEndpoint(pendingStorageServiceName) + policy.Container + "/" + createVHDBlobName(policy, dnsName)
+	return vhdMediaLink, pendingStorageServiceName, nil
 }
 
 func createLinuxProvisioningConfig(dnsName, userName, userPassword, certPath string) (ConfigurationSet, error) {
@@ -720,6 +1517,10 @@ func uploadServiceCert(dnsName, certPath string) error {
 		return err
 	}
 
+	return uploadServiceCertificate(dnsName, certificateConfig)
+}
+
+func uploadServiceCertificate(dnsName string, certificateConfig ServiceCertificate) error {
 	certificateConfigBytes, err := xml.Marshal(certificateConfig)
 	if err != nil {
 		return err
@@ -817,6 +1618,21 @@ func createNetworkConfig(os string, sshPort int) (ConfigurationSet, error) {
 	return networkConfig, nil
 }
 
+func getNetworkConfigurationSet(role *Role) *ConfigurationSet {
+	configurationSets := role.ConfigurationSets.ConfigurationSet
+	for i := 0; i < len(configurationSets); i++ {
+		if configurationSets[i].ConfigurationSetType == "NetworkConfiguration" {
+			return &configurationSets[i]
+		}
+	}
+
+	networkConfig := ConfigurationSet{}
+	networkConfig.ConfigurationSetType = "NetworkConfiguration"
+	role.ConfigurationSets.ConfigurationSet = append(configurationSets, networkConfig)
+
+	return &role.ConfigurationSets.ConfigurationSet[len(role.ConfigurationSets.ConfigurationSet)-1]
+}
+
 func createEndpoint(name string, protocol string, extertalPort int, internalPort int) InputEndpoint {
 	endpoint := InputEndpoint{}
 	endpoint.Name = name
@@ -0,0 +1,226 @@
+package vmClient
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/storageServiceClient"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/vmDiskClient"
+)
+
+const (
+	CreatedResourceStorageService     = "StorageService"
+	CreatedResourceHostedService      = "HostedService"
+	CreatedResourceServiceCertificate = "ServiceCertificate"
+	CreatedResourceVHDBlob            = "VHDBlob"
+	CreatedResourceOSDisk             = "OSDisk"
+	CreatedResourceDataDisk           = "DataDisk"
+	CreatedResourceDeployment         = "Deployment"
+
+	azureCertificateURL = "services/hostedservices/%s/certificates/%s-%s"
+
+	invalidMediaLinkError = "Invalid VHD media link: %s"
+	notRemovedError       = "Resource is kept on purpose"
+)
+
+// VMCreationError is returned when VM creation fails. Report lists the
+// resources that were created before the failure and whether they were removed.
+type VMCreationError struct {
+	Err    error
+	Report VMCreationReport
+}
+
+func (e *VMCreationError) Error() string {
+	if len(e.Report.LeftBehind) == 0 {
+		return e.Err.Error()
+	}
+
+	leftBehind := []string{}
+	for _, resource := range e.Report.LeftBehind {
+		leftBehind = append(leftBehind, resource.Type+" "+resource.Name)
+	}
+
+	return fmt.Sprintf("%s (left behind: %s)", e.Err.Error(), strings.Join(leftBehind, ", "))
+}
+
+type vmCreationTransaction struct {
+	resources []CreatedResource
+	undos     []func() error
+}
+
+//Region private methods starts
+
+func newVMCreationTransaction() *vmCreationTransaction {
+	return &vmCreationTransaction{}
+}
+
+// record adds a created resource. A nil undo keeps the resource on rollback.
+func (transaction *vmCreationTransaction) record(resourceType, name string, undo func() error) {
+	transaction.resources = append(transaction.resources, CreatedResource{Type: resourceType, Name: name})
+	transaction.undos = append(transaction.undos, undo)
+}
+
+// rollback undoes the recorded resources in reverse order and returns err
+// wrapped in a VMCreationError carrying the cleanup report.
+func (transaction *vmCreationTransaction) rollback(err error) error {
+	report := VMCreationReport{}
+	report.Created = append(report.Created, transaction.resources...)
+
+	for i := len(transaction.resources) - 1; i >= 0; i-- {
+		resource := transaction.resources[i]
+		undo := transaction.undos[i]
+		if undo == nil {
+			resource.Err = errors.New(notRemovedError)
+			report.LeftBehind = append(report.LeftBehind, resource)
+			continue
+		}
+
+		undoErr := undo()
+		if undoErr != nil {
+			resource.Err = undoErr
+			report.LeftBehind = append(report.LeftBehind, resource)
+			continue
+		}
+
+		report.CleanedUp = append(report.CleanedUp, resource)
+	}
+
+	return &VMCreationError{Err: err, Report: report}
+}
+
+func recordServiceCertificates(transaction *vmCreationTransaction, dnsName string) {
+	// The hosted service was just created, so every certificate in it was uploaded by this VM creation
+	certificates, err := getServiceCertificates(dnsName)
+	if err != nil {
+		return
+	}
+
+	for _, certificate := range certificates.Certificates {
+		thumbprintAlgorithm, thumbprint := certificate.ThumbprintAlgorithm, certificate.Thumbprint
+		transaction.record(CreatedResourceServiceCertificate, thumbprint, func() error {
+			return deleteServiceCertificate(dnsName, thumbprintAlgorithm, thumbprint)
+		})
+	}
+}
+
+// recordRoleDisks records the VHD blob and disk of every new disk of the role.
+// Disks attached by name already existed, and VM image roles may leave the OS
+// media link empty, so neither is recorded.
+func recordRoleDisks(transaction *vmCreationTransaction, role *Role) {
+	oSVirtualHardDisk := role.OSVirtualHardDisk
+	if len(oSVirtualHardDisk.MediaLink) > 0 && len(oSVirtualHardDisk.DiskName) == 0 {
+		recordDisk(transaction, CreatedResourceOSDisk, oSVirtualHardDisk.MediaLink)
+	}
+
+	for _, dataDisk := range role.DataVirtualHardDisks.DataVirtualHardDisk {
+		if len(dataDisk.MediaLink) == 0 || len(dataDisk.DiskName) > 0 {
+			continue
+		}
+
+		recordDisk(transaction, CreatedResourceDataDisk, dataDisk.MediaLink)
+	}
+}
+
+func recordDisk(transaction *vmCreationTransaction, diskType, mediaLink string) {
+	transaction.record(CreatedResourceVHDBlob, mediaLink, func() error {
+		return deleteVHDBlob(mediaLink)
+	})
+	transaction.record(diskType, mediaLink, func() error {
+		return deleteDiskByMediaLink(mediaLink)
+	})
+}
+
+func getServiceCertificates(dnsName string) (*CertificateList, error) {
+	certificateList := new(CertificateList)
+
+	requestURL := fmt.Sprintf(azureCertificatListURL, dnsName)
+	response, err := azure.SendAzureGetRequest(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, certificateList)
+	if err != nil {
+		return nil, err
+	}
+
+	return certificateList, nil
+}
+
+func deleteServiceCertificate(dnsName, thumbprintAlgorithm, thumbprint string) error {
+	requestURL := fmt.Sprintf(azureCertificateURL, dnsName, thumbprintAlgorithm, thumbprint)
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		if isResourceNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func deleteHostedServiceIfExists(dnsName string) error {
+	requestURL := fmt.Sprintf(deleteAzureHostedServiceURL, dnsName)
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		if isResourceNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func deleteDeploymentIfExists(cloudserviceName, deploymentName string) error {
+	requestURL := fmt.Sprintf(deleteAzureDeploymentURL, cloudserviceName, deploymentName)
+	requestId, err := azure.SendAzureDeleteRequest(requestURL)
+	if err != nil {
+		if isResourceNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func deleteDiskByMediaLink(mediaLink string) error {
+	disk, err := vmDiskClient.GetDiskByMediaLink(mediaLink)
+	if err != nil {
+		return err
+	}
+	if disk == nil {
+		return nil
+	}
+
+	return vmDiskClient.DeleteDisk(disk.Name)
+}
+
+func deleteVHDBlob(mediaLink string) error {
+	blobURL, err := url.Parse(mediaLink)
+	if err != nil {
+		return err
+	}
+
+	hostParts := strings.SplitN(blobURL.Host, ".blob.", 2)
+	pathParts := strings.SplitN(strings.TrimPrefix(blobURL.Path, "/"), "/", 2)
+	if len(hostParts) != 2 || len(pathParts) != 2 {
+		return fmt.Errorf(invalidMediaLinkError, mediaLink)
+	}
+
+	storageClient, err := storageServiceClient.GetStorageClientByName(hostParts[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = storageClient.GetBlobService().DeleteBlobIfExists(pathParts[0], pathParts[1])
+	return err
+}
+
+//Region private methods ends
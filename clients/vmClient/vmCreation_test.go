@@ -0,0 +1,105 @@
+package vmClient
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_recordRoleDisks(t *testing.T) {
+	role := &Role{}
+	role.OSVirtualHardDisk.MediaLink = "https://store.blob.core.windows.net/vhds/os.vhd"
+	role.DataVirtualHardDisks.DataVirtualHardDisk = []DataVirtualHardDisk{
+		{Lun: 0, MediaLink: "https://store.blob.core.windows.net/vhds/lun0.vhd"},
+		{Lun: 1},
+		{Lun: 2, DiskName: "existing", MediaLink: "https://store.blob.core.windows.net/vhds/existing.vhd"},
+		{Lun: 3, MediaLink: "https://store.blob.core.windows.net/vhds/lun3.vhd"},
+	}
+
+	transaction := newVMCreationTransaction()
+	recordRoleDisks(transaction, role)
+
+	expected := []CreatedResource{
+		{Type: CreatedResourceVHDBlob, Name: "https://store.blob.core.windows.net/vhds/os.vhd"},
+		{Type: CreatedResourceOSDisk, Name: "https://store.blob.core.windows.net/vhds/os.vhd"},
+		{Type: CreatedResourceVHDBlob, Name: "https://store.blob.core.windows.net/vhds/lun0.vhd"},
+		{Type: CreatedResourceDataDisk, Name: "https://store.blob.core.windows.net/vhds/lun0.vhd"},
+		{Type: CreatedResourceVHDBlob, Name: "https://store.blob.core.windows.net/vhds/lun3.vhd"},
+		{Type: CreatedResourceDataDisk, Name: "https://store.blob.core.windows.net/vhds/lun3.vhd"},
+	}
+
+	if len(transaction.resources) != len(expected) {
+		t.Fatalf("Expected: %d resources, got: %d", len(expected), len(transaction.resources))
+	}
+	for i, resource := range transaction.resources {
+		if resource != expected[i] {
+			t.Errorf("Expected: %v, got: %v", expected[i], resource)
+		}
+	}
+}
+
+func Test_recordRoleDisksSkipsEmptyOSMediaLink(t *testing.T) {
+	role := &Role{VMImageName: "image"}
+
+	transaction := newVMCreationTransaction()
+	recordRoleDisks(transaction, role)
+
+	if len(transaction.resources) != 0 {
+		t.Errorf("Expected: no resources, got: %v", transaction.resources)
+	}
+}
+
+func Test_rollback(t *testing.T) {
+	undone := []string{}
+	transaction := newVMCreationTransaction()
+	transaction.record(CreatedResourceStorageService, "store", func() error {
+		undone = append(undone, "store")
+		return nil
+	})
+	transaction.record(CreatedResourceHostedService, "service", func() error {
+		undone = append(undone, "service")
+		return errors.New("busy")
+	})
+	transaction.record(CreatedResourceDeployment, "deployment", nil)
+
+	err := transaction.rollback(errors.New("failed"))
+	creationErr, ok := err.(*VMCreationError)
+	if !ok {
+		t.Fatalf("Expected: *VMCreationError, got: %T", err)
+	}
+
+	if len(undone) != 2 || undone[0] != "service" || undone[1] != "store" {
+		t.Errorf("Expected: [service store], got: %v", undone)
+	}
+	if len(creationErr.Report.Created) != 3 {
+		t.Errorf("Expected: 3 created resources, got: %d", len(creationErr.Report.Created))
+	}
+	if len(creationErr.Report.CleanedUp) != 1 || creationErr.Report.CleanedUp[0].Name != "store" {
+		t.Errorf("Expected: store cleaned up, got: %v", creationErr.Report.CleanedUp)
+	}
+	if len(creationErr.Report.LeftBehind) != 2 {
+		t.Errorf("Expected: 2 resources left behind, got: %v", creationErr.Report.LeftBehind)
+	}
+
+	expectedMessage := "failed (left behind: Deployment deployment, HostedService service)"
+	if creationErr.Error() != expectedMessage {
+		t.Errorf("Expected: %s, got: %s", expectedMessage, creationErr.Error())
+	}
+}
+
+func Test_rollbackFailingUndo(t *testing.T) {
+	asyncErr := errors.New("Code: Conflict, Message: The hosted service is in use")
+	transaction := newVMCreationTransaction()
+	transaction.record(CreatedResourceHostedService, "service", func() error {
+		return asyncErr
+	})
+
+	err := transaction.rollback(errors.New("failed"))
+	report := err.(*VMCreationError).Report
+
+	if len(report.CleanedUp) != 0 {
+		t.Errorf("Expected: nothing cleaned up, got: %v", report.CleanedUp)
+	}
+	if len(report.LeftBehind) != 1 || report.LeftBehind[0].Name != "service" || report.LeftBehind[0].Err != asyncErr {
+		t.Errorf("Expected: service left behind with %s, got: %v", asyncErr, report.LeftBehind)
+	}
+}
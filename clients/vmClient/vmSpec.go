@@ -0,0 +1,539 @@
+package vmClient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"github.com/MSOpenTech/azure-sdk-for-go/clients/imageClient"
+)
+
+const (
+	VMSpecStatePresent = "present"
+	VMSpecStateAbsent  = "absent"
+
+	VMPlanActionCreate         = "Create"
+	VMPlanActionDelete         = "Delete"
+	VMPlanActionUpdateRole     = "UpdateRole"
+	VMPlanActionAddDataDisk    = "AddDataDisk"
+	VMPlanActionDeleteDataDisk = "DeleteDataDisk"
+	VMPlanActionImageDrift     = "ImageDrift"
+
+	resourceNotFoundErrorCode = "ResourceNotFound"
+	defaultSshPort            = 22
+
+	invalidVMSpecStateError  = "Invalid state %s for VM %s. Valid values are 'present' and 'absent'"
+	invalidVMPlanActionError = "Unknown VM plan action: %s"
+	invalidVMSpecsError      = "VM spec document must contain a VM spec or a list of VM specs"
+)
+
+//Region public methods starts
+
+func LoadVMSpecs(path string) ([]VMSpec, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "path")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseVMSpecs(data)
+}
+
+func ParseVMSpecs(data []byte) ([]VMSpec, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "data")
+	}
+
+	jsonData := bytes.TrimSpace(data)
+	if len(jsonData) == 0 || (jsonData[0] != '{' && jsonData[0] != '[') {
+		yamlValue, err := parseYaml(data)
+		if err != nil {
+			return nil, err
+		}
+
+		target := reflect.TypeOf(VMSpec{})
+		if _, ok := yamlValue.([]interface{}); ok {
+			target = reflect.TypeOf([]VMSpec{})
+		}
+
+		jsonData, err = json.Marshal(resolveYamlValue(yamlValue, target))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	specs := []VMSpec{}
+	switch jsonData[0] {
+	case '[':
+		err := json.Unmarshal(jsonData, &specs)
+		if err != nil {
+			return nil, err
+		}
+	case '{':
+		spec := VMSpec{}
+		err := json.Unmarshal(jsonData, &spec)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	default:
+		return nil, errors.New(invalidVMSpecsError)
+	}
+
+	for i := range specs {
+		err := normalizeVMSpec(&specs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return specs, nil
+}
+
+func PlanVMSpec(spec VMSpec) (*VMPlan, error) {
+	err := normalizeVMSpec(&spec)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := new(VMPlan)
+	plan.Spec = spec
+
+	_, err = GetVMDeployment(spec.Name, spec.Name)
+	exists := err == nil
+	if err != nil && !isResourceNotFoundError(err) {
+		return nil, err
+	}
+
+	if spec.State == VMSpecStateAbsent {
+		if exists {
+			addVMPlanAction(plan, VMPlanActionDelete, "delete cloud service %s with its deployment and disks", spec.Name)
+		}
+		return plan, nil
+	}
+
+	if !exists {
+		addVMPlanAction(plan, VMPlanActionCreate, "create VM %s (%s, %s) in %s", spec.Name, spec.Size, spec.Image, spec.Location)
+		return plan, nil
+	}
+
+	role, err := GetRole(spec.Name, spec.Name, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	imageMatches, err := isSpecImage(role.OSVirtualHardDisk.SourceImageName, spec.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	if !imageMatches {
+		if spec.ReplaceOnImageChange {
+			addVMPlanAction(plan, VMPlanActionDelete, "delete VM %s to replace image %s", spec.Name, role.OSVirtualHardDisk.SourceImageName)
+			addVMPlanAction(plan, VMPlanActionCreate, "create VM %s (%s, %s) in %s", spec.Name, spec.Size, spec.Image, spec.Location)
+			return plan, nil
+		}
+
+		// Image drift is only reported, rebuilding the VM would destroy its disks
+		addVMPlanAction(plan, VMPlanActionImageDrift, "VM %s runs image %s instead of %s, set replaceOnImageChange to rebuild it", spec.Name, role.OSVirtualHardDisk.SourceImageName, spec.Image)
+	}
+
+	err = planRoleUpdate(plan, role)
+	if err != nil {
+		return nil, err
+	}
+	planDataDisks(plan, role)
+
+	return plan, nil
+}
+
+func ApplyVMPlan(plan *VMPlan) error {
+	if plan == nil {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "plan")
+	}
+
+	spec := plan.Spec
+	for _, action := range plan.Actions {
+		var err error
+		switch action.Type {
+		case VMPlanActionCreate:
+			err = createVMFromSpec(spec)
+		case VMPlanActionDelete:
+			err = DeleteHostedService(spec.Name)
+		case VMPlanActionUpdateRole:
+			err = UpdateRole(spec.Name, spec.Name, plan.Role)
+		case VMPlanActionAddDataDisk:
+			err = AddDataDisk(spec.Name, spec.Name, spec.Name, *action.DataDisk)
+		case VMPlanActionDeleteDataDisk:
+			err = DeleteDataDisk(spec.Name, spec.Name, spec.Name, action.DataDisk.Lun, false)
+		case VMPlanActionImageDrift:
+			continue
+		default:
+			err = fmt.Errorf(invalidVMPlanActionError, action.Type)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %s", action.Description, err)
+		}
+	}
+
+	return nil
+}
+
+//Region public methods ends
+
+//Region private methods starts
+
+func normalizeVMSpec(spec *VMSpec) error {
+	if len(spec.Name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	err := verifyDNSname(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(spec.State) == 0 {
+		spec.State = VMSpecStatePresent
+	}
+	if spec.State == VMSpecStateAbsent {
+		return nil
+	}
+	if spec.State != VMSpecStatePresent {
+		return fmt.Errorf(invalidVMSpecStateError, spec.State, spec.Name)
+	}
+
+	if len(spec.Size) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "size")
+	}
+	if len(spec.Image) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "image")
+	}
+	if len(spec.Location) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "location")
+	}
+	if len(spec.UserName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "userName")
+	}
+
+	if spec.SshPort == 0 {
+		spec.SshPort = defaultSshPort
+	}
+
+	for i := range spec.Endpoints {
+		if len(spec.Endpoints[i].Name) == 0 {
+			return fmt.Errorf(azure.ParamNotSpecifiedError, "endpoints.name")
+		}
+		if len(spec.Endpoints[i].Protocol) == 0 {
+			spec.Endpoints[i].Protocol = "tcp"
+		}
+		if spec.Endpoints[i].LocalPort == 0 {
+			spec.Endpoints[i].LocalPort = spec.Endpoints[i].Port
+		}
+	}
+
+	for i := range spec.Extensions {
+		if len(spec.Extensions[i].ReferenceName) == 0 {
+			spec.Extensions[i].ReferenceName = spec.Extensions[i].Name
+		}
+		if len(spec.Extensions[i].State) == 0 {
+			spec.Extensions[i].State = "enable"
+		}
+	}
+
+	return nil
+}
+
+func addVMPlanAction(plan *VMPlan, actionType, format string, args ...interface{}) *VMPlanAction {
+	action := VMPlanAction{}
+	action.Type = actionType
+	action.Description = fmt.Sprintf(format, args...)
+
+	plan.Actions = append(plan.Actions, action)
+	return &plan.Actions[len(plan.Actions)-1]
+}
+
+func planRoleUpdate(plan *VMPlan, role *Role) error {
+	spec := plan.Spec
+	desiredRole := *role
+	changes := []string{}
+
+	if role.RoleSize != spec.Size {
+		desiredRole.RoleSize = spec.Size
+		changes = append(changes, fmt.Sprintf("resize from %s to %s", role.RoleSize, spec.Size))
+	}
+
+	desiredEndpoints := createSpecEndpoints(spec)
+	desiredRole.ConfigurationSets.ConfigurationSet = append([]ConfigurationSet{}, role.ConfigurationSets.ConfigurationSet...)
+	for i, configurationSet := range desiredRole.ConfigurationSets.ConfigurationSet {
+		if configurationSet.ConfigurationSetType != "NetworkConfiguration" {
+			continue
+		}
+		if endpointsEqual(configurationSet.InputEndpoints.InputEndpoint, desiredEndpoints) {
+			continue
+		}
+
+		desiredRole.ConfigurationSets.ConfigurationSet[i].InputEndpoints.InputEndpoint = desiredEndpoints
+		changes = append(changes, "set endpoints to "+describeEndpoints(desiredEndpoints))
+	}
+
+	desiredExtensions, err := createSpecExtensions(spec)
+	if err != nil {
+		return err
+	}
+	if !extensionsEqual(role.ResourceExtensionReferences.ResourceExtensionReference, desiredExtensions) {
+		desiredRole.ResourceExtensionReferences.ResourceExtensionReference = desiredExtensions
+		changes = append(changes, "set extensions to "+describeExtensions(desiredExtensions))
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	plan.Role = &desiredRole
+	addVMPlanAction(plan, VMPlanActionUpdateRole, "update VM %s: %s", spec.Name, strings.Join(changes, "; "))
+	return nil
+}
+
+func planDataDisks(plan *VMPlan, role *Role) {
+	spec := plan.Spec
+
+	existingDisks := make(map[int]bool)
+	for _, dataDisk := range role.DataVirtualHardDisks.DataVirtualHardDisk {
+		existingDisks[dataDisk.Lun] = true
+	}
+
+	desiredDisks := make(map[int]bool)
+	for _, diskSpec := range spec.DataDisks {
+		desiredDisks[diskSpec.Lun] = true
+		if existingDisks[diskSpec.Lun] {
+			continue
+		}
+
+		dataDisk := createSpecDataDisk(diskSpec)
+		action := addVMPlanAction(plan, VMPlanActionAddDataDisk, "attach new %d GB data disk to VM %s at LUN %d", diskSpec.SizeInGB, spec.Name, diskSpec.Lun)
+		action.DataDisk = &dataDisk
+	}
+
+	for _, dataDisk := range role.DataVirtualHardDisks.DataVirtualHardDisk {
+		if desiredDisks[dataDisk.Lun] {
+			continue
+		}
+
+		detachedDisk := dataDisk
+		action := addVMPlanAction(plan, VMPlanActionDeleteDataDisk, "detach data disk %s from VM %s at LUN %d, keeping its VHD", dataDisk.DiskName, spec.Name, dataDisk.Lun)
+		action.DataDisk = &detachedDisk
+	}
+}
+
+func createVMFromSpec(spec VMSpec) error {
+	role, err := CreateAzureVMConfiguration(spec.Name, spec.Size, spec.Image, spec.Location)
+	if err != nil {
+		return err
+	}
+
+	role, err = AddAzureLinuxProvisioningConfig(role, spec.UserName, spec.Password, "", spec.SshPort)
+	if err != nil {
+		return err
+	}
+
+	for i, configurationSet := range role.ConfigurationSets.ConfigurationSet {
+		if configurationSet.ConfigurationSetType != "NetworkConfiguration" {
+			continue
+		}
+
+		role.ConfigurationSets.ConfigurationSet[i].InputEndpoints.InputEndpoint = createSpecEndpoints(spec)
+	}
+
+	if len(spec.SshPublicKeys) > 0 {
+		role, err = AddAzureVMSshPublicKeys(role, "", spec.SshPublicKeys...)
+		if err != nil {
+			return err
+		}
+	}
+
+	role.ResourceExtensionReferences.ResourceExtensionReference, err = createSpecExtensions(spec)
+	if err != nil {
+		return err
+	}
+
+	for _, diskSpec := range spec.DataDisks {
+		dataDisk := createSpecDataDisk(diskSpec)
+		dataDisk.MediaLink, role.PendingStorageServiceName, err = createVHDMediaLink(spec.Name+"-lun"+strconv.Itoa(diskSpec.Lun), spec.Location, role.PendingStorageServiceName)
+		if err != nil {
+			return err
+		}
+
+		role.DataVirtualHardDisks.DataVirtualHardDisk = append(role.DataVirtualHardDisks.DataVirtualHardDisk, dataDisk)
+	}
+
+	if len(spec.CustomData) > 0 {
+		role, err = SetAzureVMCustomData(role, []byte(spec.CustomData))
+		if err != nil {
+			return err
+		}
+	}
+
+	return CreateAzureVM(role, spec.Name, spec.Location)
+}
+
+// isSpecImage reports whether a VM created from sourceImageName satisfies the
+// spec image, which may also be an image label or family. A newer image in the
+// same family does not make the VM outdated.
+func isSpecImage(sourceImageName, specImage string) (bool, error) {
+	if len(sourceImageName) == 0 || sourceImageName == specImage {
+		return true, nil
+	}
+
+	image, err := imageClient.GetImage(sourceImageName)
+	if err != nil {
+		// A retired source image says nothing about the spec, so it is not a mismatch
+		if isResourceNotFoundError(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return image.Label == specImage || strings.EqualFold(image.ImageFamily, specImage), nil
+}
+
+func createSpecEndpoints(spec VMSpec) []InputEndpoint {
+	endpoints := []InputEndpoint{createEndpoint("ssh", "tcp", spec.SshPort, 22)}
+	for _, endpointSpec := range spec.Endpoints {
+		endpoints = append(endpoints, createEndpoint(endpointSpec.Name, endpointSpec.Protocol, endpointSpec.Port, endpointSpec.LocalPort))
+	}
+
+	return endpoints
+}
+
+func createSpecExtensions(spec VMSpec) ([]ResourceExtensionReference, error) {
+	role := new(Role)
+	for _, extensionSpec := range spec.Extensions {
+		version := extensionSpec.Version
+		if version == ExtensionVersionLatest {
+			resolvedVersion, err := ResolveExtensionVersion(extensionSpec.Publisher, extensionSpec.Name, version)
+			if err != nil {
+				return nil, err
+			}
+			version = resolvedVersion
+		}
+
+		_, err := SetAzureVMExtension(role, extensionSpec.Name, extensionSpec.Publisher, version, extensionSpec.ReferenceName, extensionSpec.State, extensionSpec.PublicConfig, extensionSpec.PrivateConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return role.ResourceExtensionReferences.ResourceExtensionReference, nil
+}
+
+func createSpecDataDisk(diskSpec DataDiskSpec) DataVirtualHardDisk {
+	dataDisk := DataVirtualHardDisk{}
+	dataDisk.Lun = diskSpec.Lun
+	dataDisk.LogicalDiskSizeInGB = diskSpec.SizeInGB
+	dataDisk.DiskLabel = diskSpec.Label
+	dataDisk.HostCaching = diskSpec.HostCaching
+
+	return dataDisk
+}
+
+func endpointsEqual(existing, desired []InputEndpoint) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+
+	existingByName := make(map[string]InputEndpoint)
+	for _, endpoint := range existing {
+		existingByName[endpoint.Name] = endpoint
+	}
+
+	for _, endpoint := range desired {
+		existingEndpoint, ok := existingByName[endpoint.Name]
+		if !ok || existingEndpoint.Port != endpoint.Port || existingEndpoint.LocalPort != endpoint.LocalPort || existingEndpoint.Protocol != endpoint.Protocol {
+			return false
+		}
+	}
+
+	return true
+}
+
+func extensionsEqual(existing, desired []ResourceExtensionReference) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+
+	existingByReference := make(map[string]ResourceExtensionReference)
+	for _, extension := range existing {
+		existingByReference[extension.ReferenceName] = extension
+	}
+
+	for _, extension := range desired {
+		existingExtension, ok := existingByReference[extension.ReferenceName]
+		if !ok || existingExtension.Name != extension.Name || existingExtension.Publisher != extension.Publisher || existingExtension.Version != extension.Version {
+			return false
+		}
+		if getExtensionPublicConfig(existingExtension) != getExtensionPublicConfig(extension) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getExtensionPublicConfig(extension ResourceExtensionReference) string {
+	for _, parameter := range extension.ResourceExtensionParameterValues.ResourceExtensionParameterValue {
+		if parameter.Type != "Public" {
+			continue
+		}
+
+		config, err := base64.StdEncoding.DecodeString(parameter.Value)
+		if err != nil {
+			return parameter.Value
+		}
+		return string(config)
+	}
+
+	return ""
+}
+
+func describeEndpoints(endpoints []InputEndpoint) string {
+	var description bytes.Buffer
+	for i, endpoint := range endpoints {
+		if i > 0 {
+			description.WriteString(", ")
+		}
+		description.WriteString(fmt.Sprintf("%s %s/%d->%d", endpoint.Name, endpoint.Protocol, endpoint.Port, endpoint.LocalPort))
+	}
+
+	return "[" + description.String() + "]"
+}
+
+func describeExtensions(extensions []ResourceExtensionReference) string {
+	var description bytes.Buffer
+	for i, extension := range extensions {
+		if i > 0 {
+			description.WriteString(", ")
+		}
+		description.WriteString(fmt.Sprintf("%s %s.%s %s", extension.ReferenceName, extension.Publisher, extension.Name, extension.Version))
+	}
+
+	return "[" + description.String() + "]"
+}
+
+func isResourceNotFoundError(err error) bool {
+	azureErr, ok := err.(*azure.AzureError)
+	return ok && azureErr.Code == resourceNotFoundErrorCode
+}
+
+//Region private methods ends
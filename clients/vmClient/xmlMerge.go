@@ -0,0 +1,163 @@
+package vmClient
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is a generic XML element used to keep the elements of a management
+// API response that the entity structs do not model.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+// listItemKeys maps list item elements to the child element identifying them.
+var listItemKeys = map[string]string{
+	"ConfigurationSet":                "ConfigurationSetType",
+	"InputEndpoint":                   "Name",
+	"ResourceExtensionReference":      "ReferenceName",
+	"ResourceExtensionParameterValue": "Type",
+	"DataVirtualHardDisk":             "Lun",
+	"PublicKey":                       "Fingerprint",
+	"KeyPair":                         "Fingerprint",
+}
+
+// fullyModelledLists are list elements whose items are all represented in the
+// entity structs, so items missing from the update were removed on purpose.
+var fullyModelledLists = map[string]bool{
+	"ConfigurationSets":                true,
+	"InputEndpoints":                   true,
+	"ResourceExtensionReferences":      true,
+	"ResourceExtensionParameterValues": true,
+	"DataVirtualHardDisks":             true,
+	"PublicKeys":                       true,
+	"KeyPairs":                         true,
+}
+
+//Region private methods starts
+
+// mergeXml overlays the elements of update onto original. Elements present only
+// in original are kept in place unless they belong to a fully modelled list.
+func mergeXml(original, update []byte) ([]byte, error) {
+	originalNode := xmlNode{}
+	err := xml.Unmarshal(original, &originalNode)
+	if err != nil {
+		return nil, err
+	}
+
+	updateNode := xmlNode{}
+	err = xml.Unmarshal(update, &updateNode)
+	if err != nil {
+		return nil, err
+	}
+
+	mergedNode := mergeXmlNodes(originalNode, updateNode)
+	clearXmlNamespaces(&mergedNode, originalNode.XMLName.Space)
+	mergedNode.Attrs = append(mergedNode.Attrs, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: originalNode.XMLName.Space})
+
+	return xml.Marshal(mergedNode)
+}
+
+func mergeXmlNodes(original, update xmlNode) xmlNode {
+	if len(update.Children) == 0 && (len(original.Children) == 0 || len(strings.TrimSpace(update.Content)) > 0) {
+		return update
+	}
+
+	merged := original
+	merged.Children = []xmlNode{}
+
+	updateKeys := make([]string, len(update.Children))
+	updateNames := make(map[string]bool)
+	consumed := make(map[string]bool)
+	updateIndex := make(map[string]int)
+	for i, child := range update.Children {
+		updateKeys[i] = getXmlNodeKey(child, update.Children[:i])
+		updateNames[child.XMLName.Local] = true
+		updateIndex[updateKeys[i]] = i
+	}
+
+	mergedPositions := make(map[int]int)
+	for i, child := range original.Children {
+		key := getXmlNodeKey(child, original.Children[:i])
+		if j, ok := updateIndex[key]; ok {
+			mergedPositions[j] = len(merged.Children)
+			merged.Children = append(merged.Children, mergeXmlNodes(child, update.Children[j]))
+			consumed[key] = true
+			continue
+		}
+
+		if fullyModelledLists[original.XMLName.Local] || updateNames[child.XMLName.Local] {
+			continue
+		}
+
+		merged.Children = append(merged.Children, child)
+	}
+
+	for i, child := range update.Children {
+		if consumed[updateKeys[i]] {
+			continue
+		}
+
+		position := 0
+		for j := i - 1; j >= 0; j-- {
+			if previous, ok := mergedPositions[j]; ok {
+				position = previous + 1
+				break
+			}
+		}
+
+		merged.Children = append(merged.Children[:position], append([]xmlNode{child}, merged.Children[position:]...)...)
+		for j, previous := range mergedPositions {
+			if previous >= position {
+				mergedPositions[j] = previous + 1
+			}
+		}
+		mergedPositions[i] = position
+	}
+
+	return merged
+}
+
+func getXmlNodeKey(node xmlNode, previousSiblings []xmlNode) string {
+	if keyName, ok := listItemKeys[node.XMLName.Local]; ok {
+		for _, child := range node.Children {
+			if child.XMLName.Local == keyName {
+				return node.XMLName.Local + "/" + strings.TrimSpace(child.Content)
+			}
+		}
+	}
+
+	occurrence := 0
+	for _, sibling := range previousSiblings {
+		if sibling.XMLName.Local == node.XMLName.Local {
+			occurrence++
+		}
+	}
+
+	return node.XMLName.Local + "#" + strconv.Itoa(occurrence)
+}
+
+func clearXmlNamespaces(node *xmlNode, namespace string) {
+	if node.XMLName.Space == namespace {
+		node.XMLName.Space = ""
+	}
+
+	attrs := []xml.Attr{}
+	for _, attr := range node.Attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	node.Attrs = attrs
+
+	for i := range node.Children {
+		clearXmlNamespaces(&node.Children[i], namespace)
+	}
+}
+
+//Region private methods ends
@@ -0,0 +1,66 @@
+package vmClient
+
+import (
+	"testing"
+)
+
+func Test_mergeXml(t *testing.T) {
+	testCases := []struct {
+		name     string
+		original string
+		update   string
+		expected string
+	}{
+		{
+			"scalar is replaced and unmodelled element kept",
+			`<Role xmlns="ns"><RoleName>web1</RoleName><RoleSize>Small</RoleSize><Unknown>x</Unknown></Role>`,
+			`<Role xmlns="ns"><RoleName>web1</RoleName><RoleSize>Large</RoleSize></Role>`,
+			`<Role xmlns="ns"><RoleName>web1</RoleName><RoleSize>Large</RoleSize><Unknown>x</Unknown></Role>`,
+		},
+		{
+			"keyed list items are merged and removed items dropped",
+			`<Role xmlns="ns"><InputEndpoints><InputEndpoint><Name>ssh</Name><Port>22</Port><Extra>1</Extra></InputEndpoint><InputEndpoint><Name>http</Name><Port>80</Port></InputEndpoint></InputEndpoints></Role>`,
+			`<Role xmlns="ns"><InputEndpoints><InputEndpoint><Name>ssh</Name><Port>2222</Port></InputEndpoint></InputEndpoints></Role>`,
+			`<Role xmlns="ns"><InputEndpoints><InputEndpoint><Name>ssh</Name><Port>2222</Port><Extra>1</Extra></InputEndpoint></InputEndpoints></Role>`,
+		},
+		{
+			"new list items are inserted after their predecessor",
+			`<Role xmlns="ns"><DataVirtualHardDisks><DataVirtualHardDisk><Lun>0</Lun></DataVirtualHardDisk></DataVirtualHardDisks><Last>z</Last></Role>`,
+			`<Role xmlns="ns"><DataVirtualHardDisks><DataVirtualHardDisk><Lun>0</Lun></DataVirtualHardDisk><DataVirtualHardDisk><Lun>1</Lun></DataVirtualHardDisk></DataVirtualHardDisks></Role>`,
+			`<Role xmlns="ns"><DataVirtualHardDisks><DataVirtualHardDisk><Lun>0</Lun></DataVirtualHardDisk><DataVirtualHardDisk><Lun>1</Lun></DataVirtualHardDisk></DataVirtualHardDisks><Last>z</Last></Role>`,
+		},
+		{
+			"repeated unkeyed elements are matched by position",
+			`<Role xmlns="ns"><Value>a</Value><Value>b</Value></Role>`,
+			`<Role xmlns="ns"><Value>c</Value></Role>`,
+			`<Role xmlns="ns"><Value>c</Value></Role>`,
+		},
+		{
+			"empty update keeps nested original",
+			`<Role xmlns="ns"><OSVirtualHardDisk><MediaLink>m</MediaLink></OSVirtualHardDisk></Role>`,
+			`<Role xmlns="ns"><OSVirtualHardDisk></OSVirtualHardDisk></Role>`,
+			`<Role xmlns="ns"><OSVirtualHardDisk><MediaLink>m</MediaLink></OSVirtualHardDisk></Role>`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := mergeXml([]byte(testCase.original), []byte(testCase.update))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", testCase.name, err)
+			continue
+		}
+
+		if string(output) != testCase.expected {
+			t.Errorf("%s: expected: %s, got: %s", testCase.name, testCase.expected, output)
+		}
+	}
+}
+
+func Test_mergeXmlInvalid(t *testing.T) {
+	if _, err := mergeXml([]byte("<Role>"), []byte("<Role/>")); err == nil {
+		t.Errorf("Expected an error for invalid original XML")
+	}
+	if _, err := mergeXml([]byte("<Role/>"), []byte("not xml")); err == nil {
+		t.Errorf("Expected an error for invalid update XML")
+	}
+}
@@ -0,0 +1,470 @@
+package vmClient
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	invalidYamlError = "Invalid YAML at line %d: %s"
+)
+
+var (
+	yamlEscapes = map[byte]string{
+		'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+		'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"",
+		'/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028",
+		'P': "\u2029",
+	}
+	yamlHexEscapeLengths = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+)
+
+// yamlScalar is a plain scalar whose type depends on the field it is decoded into.
+type yamlScalar string
+
+// yamlParser reads the block style subset of YAML used by VM spec files:
+// mappings, sequences, plain and quoted scalars, "|" and ">" block scalars and
+// single line flow sequences. Quoted scalars must fit on one line. Anchors, tags
+// and multi-document streams are not supported.
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+//Region private methods starts
+
+func parseYaml(data []byte) (interface{}, error) {
+	parser := &yamlParser{lines: strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")}
+
+	parser.skipIgnorableLines()
+	if parser.pos >= len(parser.lines) {
+		return nil, nil
+	}
+
+	indent, _ := parser.currentLine()
+	value, err := parser.parseBlock(indent)
+	if err != nil {
+		return nil, err
+	}
+
+	parser.skipIgnorableLines()
+	if parser.pos < len(parser.lines) {
+		return nil, parser.errorf("unexpected indentation")
+	}
+
+	return value, nil
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	_, text := p.currentLine()
+	if isYamlSequenceItem(text) {
+		return p.parseSequence(indent)
+	}
+
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := make(map[string]interface{})
+
+	for {
+		p.skipIgnorableLines()
+		if p.pos >= len(p.lines) {
+			break
+		}
+
+		lineIndent, text := p.currentLine()
+		if lineIndent < indent {
+			break
+		}
+		if lineIndent > indent || isYamlSequenceItem(text) {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		key, rest, ok := splitYamlKey(text)
+		if !ok {
+			return nil, p.errorf("expected \"key: value\"")
+		}
+
+		value, err := p.parseValue(indent, rest, true)
+		if err != nil {
+			return nil, err
+		}
+
+		mapping[key] = value
+	}
+
+	return mapping, nil
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	sequence := []interface{}{}
+
+	for {
+		p.skipIgnorableLines()
+		if p.pos >= len(p.lines) {
+			break
+		}
+
+		lineIndent, text := p.currentLine()
+		if lineIndent < indent || (lineIndent == indent && !isYamlSequenceItem(text)) {
+			break
+		}
+		if lineIndent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		item := strings.TrimLeft(text[1:], " ")
+		if _, _, ok := splitYamlKey(item); ok && !strings.HasPrefix(item, "\"") && !strings.HasPrefix(item, "'") {
+			// "- key: value" starts a mapping indented at the position of its first key
+			itemIndent := lineIndent + len(text) - len(item)
+			p.lines[p.pos] = strings.Repeat(" ", itemIndent) + item
+
+			value, err := p.parseMapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+
+			sequence = append(sequence, value)
+			continue
+		}
+
+		value, err := p.parseValue(indent, item, false)
+		if err != nil {
+			return nil, err
+		}
+
+		sequence = append(sequence, value)
+	}
+
+	return sequence, nil
+}
+
+func (p *yamlParser) parseValue(indent int, text string, inMapping bool) (interface{}, error) {
+	text = stripYamlComment(text)
+	p.pos++
+
+	if strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">") {
+		return p.parseBlockScalar(indent, text), nil
+	}
+
+	if len(text) > 0 {
+		return parseYamlScalar(text)
+	}
+
+	p.skipIgnorableLines()
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+
+	nextIndent, nextText := p.currentLine()
+	if nextIndent > indent {
+		return p.parseBlock(nextIndent)
+	}
+	if inMapping && nextIndent == indent && isYamlSequenceItem(nextText) {
+		return p.parseSequence(indent)
+	}
+
+	return nil, nil
+}
+
+func (p *yamlParser) parseBlockScalar(indent int, header string) string {
+	folded := strings.HasPrefix(header, ">")
+	strip := strings.Contains(header, "-")
+	keep := strings.Contains(header, "+")
+
+	blockLines := []string{}
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if len(strings.TrimSpace(line)) == 0 {
+			blockLines = append(blockLines, "")
+			continue
+		}
+
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if lineIndent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = lineIndent
+		}
+		if lineIndent < blockIndent {
+			break
+		}
+
+		blockLines = append(blockLines, line[blockIndent:])
+	}
+
+	trailing := 0
+	for trailing < len(blockLines) && blockLines[len(blockLines)-1-trailing] == "" {
+		trailing++
+	}
+	content := blockLines[:len(blockLines)-trailing]
+
+	separator := "\n"
+	if folded {
+		separator = " "
+	}
+
+	value := strings.Join(content, separator)
+	if strip || len(content) == 0 {
+		return value
+	}
+	if keep {
+		return value + strings.Repeat("\n", trailing+1)
+	}
+
+	return value + "\n"
+}
+
+func (p *yamlParser) currentLine() (int, string) {
+	line := p.lines[p.pos]
+	text := strings.TrimLeft(line, " ")
+	return len(line) - len(text), strings.TrimRight(text, " \t")
+}
+
+func (p *yamlParser) skipIgnorableLines() {
+	for p.pos < len(p.lines) {
+		text := strings.TrimSpace(p.lines[p.pos])
+		if len(text) != 0 && !strings.HasPrefix(text, "#") && text != "---" {
+			return
+		}
+
+		p.pos++
+	}
+}
+
+func (p *yamlParser) errorf(reason string) error {
+	return fmt.Errorf(invalidYamlError, p.pos+1, reason)
+}
+
+func isYamlSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func splitYamlKey(text string) (string, string, bool) {
+	i := findYamlUnquoted(text, func(i int) bool {
+		return (text[i] == '#' && (i == 0 || text[i-1] == ' ')) ||
+			(text[i] == ':' && (i == len(text)-1 || text[i+1] == ' '))
+	})
+	if i < 0 || text[i] == '#' {
+		return "", "", false
+	}
+
+	key := strings.TrimSpace(text[:i])
+	if unquoted, err := parseYamlScalar(key); err == nil {
+		if s, ok := unquoted.(string); ok {
+			key = s
+		}
+	}
+
+	return key, strings.TrimSpace(text[i+1:]), len(key) > 0
+}
+
+func stripYamlComment(text string) string {
+	i := findYamlUnquoted(text, func(i int) bool {
+		return text[i] == '#' && (i == 0 || text[i-1] == ' ')
+	})
+	if i >= 0 {
+		text = text[:i]
+	}
+
+	return strings.TrimSpace(text)
+}
+
+// findYamlUnquoted returns the index of the first byte outside quoted strings
+// for which match returns true, or -1.
+func findYamlUnquoted(text string, match func(i int) bool) int {
+	inQuote := byte(0)
+	for i := 0; i < len(text); i++ {
+		switch {
+		case inQuote == '"' && text[i] == '\\':
+			i++
+		case inQuote != 0:
+			if text[i] == inQuote {
+				inQuote = 0
+			}
+		case text[i] == '"' || text[i] == '\'':
+			inQuote = text[i]
+		case match(i):
+			return i
+		}
+	}
+
+	return -1
+}
+
+func splitYamlFlowSequence(text string) []string {
+	items := []string{}
+	for {
+		i := findYamlUnquoted(text, func(i int) bool { return text[i] == ',' })
+		if i < 0 {
+			break
+		}
+
+		items = append(items, strings.TrimSpace(text[:i]))
+		text = text[i+1:]
+	}
+
+	if item := strings.TrimSpace(text); len(item) > 0 {
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// parseYamlScalar returns quoted scalars as strings and plain scalars as
+// yamlScalar values, which are typed later by resolveYamlValue.
+func parseYamlScalar(text string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, "\""):
+		return unquoteYamlDoubleQuoted(text)
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf(invalidYamlError, 0, "unterminated string "+text)
+		}
+		return strings.Replace(text[1:len(text)-1], "''", "'", -1), nil
+	case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+		items := []interface{}{}
+		for _, item := range splitYamlFlowSequence(text[1 : len(text)-1]) {
+			value, err := parseYamlScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	}
+
+	return yamlScalar(text), nil
+}
+
+// unquoteYamlDoubleQuoted applies the YAML escape sequences of a double quoted
+// scalar, which differ from the Go ones: "\/", "\e", "\N", "\_", "\L", "\P"
+// and "\ " are valid, octal escapes are not.
+func unquoteYamlDoubleQuoted(text string) (string, error) {
+	if len(text) < 2 || !strings.HasSuffix(text, "\"") {
+		return "", fmt.Errorf(invalidYamlError, 0, "unterminated string "+text)
+	}
+
+	var unquoted bytes.Buffer
+	for i := 1; i < len(text)-1; i++ {
+		switch text[i] {
+		case '"':
+			return "", fmt.Errorf(invalidYamlError, 0, "unescaped quote in "+text)
+		case '\\':
+		default:
+			unquoted.WriteByte(text[i])
+			continue
+		}
+
+		i++
+		if i == len(text)-1 {
+			return "", fmt.Errorf(invalidYamlError, 0, "unterminated string "+text)
+		}
+
+		if escaped, ok := yamlEscapes[text[i]]; ok {
+			unquoted.WriteString(escaped)
+			continue
+		}
+
+		digits, ok := yamlHexEscapeLengths[text[i]]
+		if !ok || i+digits >= len(text)-1 {
+			return "", fmt.Errorf(invalidYamlError, 0, "invalid escape sequence in "+text)
+		}
+
+		code, err := strconv.ParseUint(text[i+1:i+1+digits], 16, 32)
+		if err != nil || code > unicode.MaxRune {
+			return "", fmt.Errorf(invalidYamlError, 0, "invalid escape sequence in "+text)
+		}
+
+		unquoted.WriteRune(rune(code))
+		i += digits
+	}
+
+	return unquoted.String(), nil
+}
+
+// resolveYamlValue types the plain scalars of a parsed document. Scalars decoded
+// into string fields of target keep their text, so "password: 12345" stays a
+// string; the others become booleans, numbers or null where they look like one.
+func resolveYamlValue(value interface{}, target reflect.Type) interface{} {
+	for target != nil && target.Kind() == reflect.Ptr {
+		target = target.Elem()
+	}
+
+	switch typedValue := value.(type) {
+	case yamlScalar:
+		if target != nil && target.Kind() == reflect.String {
+			return string(typedValue)
+		}
+		return resolveYamlPlainScalar(string(typedValue))
+	case map[string]interface{}:
+		for key, item := range typedValue {
+			typedValue[key] = resolveYamlValue(item, getYamlFieldType(target, key))
+		}
+	case []interface{}:
+		var elemType reflect.Type
+		if target != nil && (target.Kind() == reflect.Slice || target.Kind() == reflect.Array) {
+			elemType = target.Elem()
+		}
+		for i, item := range typedValue {
+			typedValue[i] = resolveYamlValue(item, elemType)
+		}
+	}
+
+	return value
+}
+
+// getYamlFieldType returns the type a mapping key decodes into, matching struct
+// fields by JSON name the way encoding/json does.
+func getYamlFieldType(target reflect.Type, key string) reflect.Type {
+	if target == nil {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Map:
+		return target.Elem()
+	case reflect.Struct:
+		for i := 0; i < target.NumField(); i++ {
+			field := target.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if len(name) == 0 {
+				name = field.Name
+			}
+			if strings.EqualFold(name, key) {
+				return field.Type
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveYamlPlainScalar(text string) interface{} {
+	switch text {
+	case "true", "True", "TRUE", "yes", "Yes":
+		return true
+	case "false", "False", "FALSE", "no", "No":
+		return false
+	case "null", "Null", "NULL", "~":
+		return nil
+	}
+
+	if number, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return number
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number
+	}
+
+	return text
+}
+
+//Region private methods ends
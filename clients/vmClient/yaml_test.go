@@ -0,0 +1,170 @@
+package vmClient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseYaml(t *testing.T) {
+	testCases := []struct {
+		name     string
+		yaml     string
+		expected interface{}
+	}{
+		{"mapping", "name: web1\nsize: Small\n", map[string]interface{}{"name": "web1", "size": "Small"}},
+		{"nested mapping", "vm:\n  name: web1\n  disk:\n    lun: 0\n", map[string]interface{}{"vm": map[string]interface{}{"name": "web1", "disk": map[string]interface{}{"lun": int64(0)}}}},
+		{"sequence", "- a\n- b\n", []interface{}{"a", "b"}},
+		{"sequence in mapping", "packages:\n- git\n- nginx\nname: x\n", map[string]interface{}{"packages": []interface{}{"git", "nginx"}, "name": "x"}},
+		{"indented sequence", "packages:\n  - git\n  - nginx\n", map[string]interface{}{"packages": []interface{}{"git", "nginx"}}},
+		{"sequence of mappings", "- name: http\n  port: 80\n- name: ssh\n  port: 22\n", []interface{}{
+			map[string]interface{}{"name": "http", "port": int64(80)},
+			map[string]interface{}{"name": "ssh", "port": int64(22)},
+		}},
+		{"flow sequence", "keys: [a, \"b, c\", 'd']\n", map[string]interface{}{"keys": []interface{}{"a", "b, c", "d"}}},
+		{"flow sequence with escaped quote", "keys: [\"a\\\", b\", c]\n", map[string]interface{}{"keys": []interface{}{"a\", b", "c"}}},
+		{"literal block scalar", "data: |\n  line 1\n  line 2\nnext: x\n", map[string]interface{}{"data": "line 1\nline 2\n", "next": "x"}},
+		{"folded block scalar", "data: >\n  line 1\n  line 2\n", map[string]interface{}{"data": "line 1 line 2\n"}},
+		{"stripped block scalar", "data: |-\n  line 1\n\n", map[string]interface{}{"data": "line 1"}},
+		{"kept block scalar", "data: |+\n  line 1\n\n", map[string]interface{}{"data": "line 1\n\n\n"}},
+		{"double quoted", "text: \"a: b # c\\n\"\n", map[string]interface{}{"text": "a: b # c\n"}},
+		{"double quoted yaml escapes", "url: \"https:\\/\\/example.com\\/\\x41\\_b\"\n", map[string]interface{}{"url": "https://example.com/A\u00a0b"}},
+		{"single quoted", "text: 'it''s # here'\n", map[string]interface{}{"text": "it's # here"}},
+		{"quoted key", "\"a: b\": c\n", map[string]interface{}{"a: b": "c"}},
+		{"comments", "# header\nname: web1 # trailing\n\n  # indented comment\nurl: http://host/#anchor\n", map[string]interface{}{"name": "web1", "url": "http://host/#anchor"}},
+		{"scalars", "a: true\nb: no\nc: ~\nd: 1.5\ne: 0644\nf: \"12\"\n", map[string]interface{}{"a": true, "b": false, "c": nil, "d": 1.5, "e": int64(644), "f": "12"}},
+		{"document marker", "---\nname: web1\n", map[string]interface{}{"name": "web1"}},
+		{"empty document", "# nothing\n", nil},
+	}
+
+	for _, testCase := range testCases {
+		value, err := parseYaml([]byte(testCase.yaml))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", testCase.name, err)
+			continue
+		}
+
+		if output := resolveYamlValue(value, nil); !reflect.DeepEqual(output, testCase.expected) {
+			t.Errorf("%s: expected: %#v, got: %#v", testCase.name, testCase.expected, output)
+		}
+	}
+}
+
+func Test_parseYamlErrors(t *testing.T) {
+	testCases := []string{
+		"name: web1\n  size: Small\n",
+		"name web1\n",
+		"- a\nname: b\n",
+		"text: 'unterminated\n",
+		"text: \"bad \\q escape\"\n",
+		"text: \"go octal \\101\"\n",
+	}
+
+	for _, testCase := range testCases {
+		if _, err := parseYaml([]byte(testCase)); err == nil {
+			t.Errorf("Expected an error for '%s'", testCase)
+		}
+	}
+}
+
+func Test_resolveYamlValue(t *testing.T) {
+	value, err := parseYaml([]byte("password: 12345\nsshPort: 2222\nsshPublicKeys: [0644, true]\nextensions:\n  - version: 1.0\n    state: yes\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"password":      "12345",
+		"sshPort":       int64(2222),
+		"sshPublicKeys": []interface{}{"0644", "true"},
+		"extensions":    []interface{}{map[string]interface{}{"version": "1.0", "state": "yes"}},
+	}
+	if output := resolveYamlValue(value, reflect.TypeOf(VMSpec{})); !reflect.DeepEqual(output, expected) {
+		t.Errorf("Expected: %#v, got: %#v", expected, output)
+	}
+}
+
+func Test_ParseVMSpecsYaml(t *testing.T) {
+	data := "- name: web1\n" +
+		"  size: Small\n" +
+		"  image: Ubuntu Server 14.04 LTS\n" +
+		"  location: West US\n" +
+		"  userName: azure\n" +
+		"  password: 12345\n" +
+		"  sshPublicKeys: [\"ssh-rsa AAA, x\"]\n" +
+		"  endpoints:\n" +
+		"    - name: http\n" +
+		"      port: 80\n" +
+		"  customData: |\n" +
+		"    #!/bin/sh\n" +
+		"    echo hi\n"
+
+	specs, err := ParseVMSpecs([]byte(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(specs) != 1 {
+		t.Fatalf("Expected one spec, got %d", len(specs))
+	}
+
+	spec := specs[0]
+	if spec.Password != "12345" {
+		t.Errorf("Wrong password: %s", spec.Password)
+	}
+	if !reflect.DeepEqual(spec.SshPublicKeys, []string{"ssh-rsa AAA, x"}) {
+		t.Errorf("Wrong SSH public keys: %#v", spec.SshPublicKeys)
+	}
+	if len(spec.Endpoints) != 1 || spec.Endpoints[0].Port != 80 || spec.Endpoints[0].Name != "http" {
+		t.Errorf("Wrong endpoints: %#v", spec.Endpoints)
+	}
+	if spec.CustomData != "#!/bin/sh\necho hi\n" {
+		t.Errorf("Wrong custom data: %q", spec.CustomData)
+	}
+}
+
+func Test_unquoteYamlDoubleQuoted(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected string
+	}{
+		{`"plain"`, "plain"},
+		{`""`, ""},
+		{`"http:\/\/example.com\/"`, "http://example.com/"},
+		{`"\0\a\b\t\	\n\v\f\r\e"`, "\x00\a\b\t\t\n\v\f\r\x1b"},
+		{`"a\ b \"c\" \\d"`, "a b \"c\" \\d"},
+		{`"\N\_\L\P"`, "\u0085\u00a0\u2028\u2029"},
+		{`"\x41\u00e9\U0001F600"`, "A\u00e9\U0001F600"},
+		{`"caf\u00E9"`, "caf\u00e9"},
+	}
+
+	for _, testCase := range testCases {
+		output, err := unquoteYamlDoubleQuoted(testCase.text)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", testCase.text, err)
+			continue
+		}
+		if output != testCase.expected {
+			t.Errorf("%s: expected: %q, got: %q", testCase.text, testCase.expected, output)
+		}
+	}
+}
+
+func Test_unquoteYamlDoubleQuotedErrors(t *testing.T) {
+	testCases := []string{
+		`"`,
+		`"unterminated`,
+		`"trailing escape\"`,
+		`"inner " quote"`,
+		`"\q"`,
+		`"\101"`,
+		`"\x4"`,
+		`"\u00g9"`,
+		`"\U00110000"`,
+		`"\x+1"`,
+	}
+
+	for _, testCase := range testCases {
+		if output, err := unquoteYamlDoubleQuoted(testCase); err == nil {
+			t.Errorf("%s: expected an error, got: %q", testCase, output)
+		}
+	}
+}
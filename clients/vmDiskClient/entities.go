@@ -0,0 +1,29 @@
+package vmDiskClient
+
+import (
+	"encoding/xml"
+)
+
+type DiskList struct {
+	XMLName xml.Name `xml:"Disks"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Disks   []Disk   `xml:"Disk"`
+}
+
+type Disk struct {
+	AffinityGroup       string
+	AttachedTo          *DiskAttachment
+	OS                  string
+	IsCorrupted         bool
+	Location            string
+	LogicalDiskSizeInGB int
+	MediaLink           string
+	Name                string
+	SourceImageName     string
+}
+
+type DiskAttachment struct {
+	HostedServiceName string
+	DeploymentName    string
+	RoleName          string
+}
@@ -1,16 +1,54 @@
 package vmDiskClient
 
 import (
+	"encoding/xml"
 	"fmt"
 	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"strings"
 )
 
 const (
-	azureVMDiskURL = "services/disks/%s"
+	azureVMDiskListURL = "services/disks"
+	azureVMDiskURL     = "services/disks/%s"
 )
 
 //Region public methods starts
 
+func GetDiskList() (*DiskList, error) {
+	diskList := new(DiskList)
+
+	response, err := azure.SendAzureGetRequest(azureVMDiskListURL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, diskList)
+	if err != nil {
+		return nil, err
+	}
+
+	return diskList, nil
+}
+
+func GetDiskByMediaLink(mediaLink string) (*Disk, error) {
+	if len(mediaLink) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "mediaLink")
+	}
+
+	diskList, err := GetDiskList()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, disk := range diskList.Disks {
+		if strings.EqualFold(disk.MediaLink, mediaLink) {
+			return &disk, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func DeleteDisk(diskName string) error {
 	if len(diskName) == 0 {
 		return fmt.Errorf(azure.ParamNotSpecifiedError, "diskName")
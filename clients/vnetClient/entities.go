@@ -0,0 +1,87 @@
+package vnetClient
+
+import (
+	"encoding/xml"
+)
+
+type NetworkConfiguration struct {
+	XMLName                     xml.Name                    `xml:"NetworkConfiguration"`
+	Xmlns                       string                      `xml:"xmlns,attr"`
+	VirtualNetworkConfiguration VirtualNetworkConfiguration `xml:"VirtualNetworkConfiguration"`
+}
+
+type VirtualNetworkConfiguration struct {
+	Dns                 Dns                  `xml:"Dns,omitempty"`
+	LocalNetworkSites   []LocalNetworkSite   `xml:"LocalNetworkSites>LocalNetworkSite"`
+	VirtualNetworkSites []VirtualNetworkSite `xml:"VirtualNetworkSites>VirtualNetworkSite"`
+	UnknownElements     []UnknownElement     `xml:",any"`
+}
+
+type Dns struct {
+	DnsServers      []DnsServer      `xml:"DnsServers>DnsServer,omitempty"`
+	UnknownElements []UnknownElement `xml:",any"`
+}
+
+type DnsServer struct {
+	XMLName   xml.Name `xml:"DnsServer"`
+	Name      string   `xml:"name,attr"`
+	IPAddress string   `xml:"IPAddress,attr"`
+}
+
+type DnsServerRef struct {
+	Name string `xml:"name,attr"`
+}
+
+type LocalNetworkSite struct {
+	Name              string `xml:"name,attr"`
+	AddressSpace      AddressSpace
+	VPNGatewayAddress string
+	UnknownAttrs      []xml.Attr       `xml:",any,attr"`
+	UnknownElements   []UnknownElement `xml:",any"`
+}
+
+type VirtualNetworkSite struct {
+	Name            string           `xml:"name,attr"`
+	Location        string           `xml:"Location,attr,omitempty"`
+	AffinityGroup   string           `xml:"AffinityGroup,attr,omitempty"`
+	AddressSpace    AddressSpace     `xml:"AddressSpace"`
+	Subnets         []Subnet         `xml:"Subnets>Subnet,omitempty"`
+	DnsServersRef   []DnsServerRef   `xml:"DnsServersRef>DnsServerRef,omitempty"`
+	Gateway         *Gateway         `xml:"Gateway,omitempty"`
+	UnknownAttrs    []xml.Attr       `xml:",any,attr"`
+	UnknownElements []UnknownElement `xml:",any"`
+}
+
+type AddressSpace struct {
+	AddressPrefix []string `xml:"AddressPrefix"`
+}
+
+type Subnet struct {
+	Name            string           `xml:"name,attr"`
+	AddressPrefix   string           `xml:"AddressPrefix"`
+	UnknownAttrs    []xml.Attr       `xml:",any,attr"`
+	UnknownElements []UnknownElement `xml:",any"`
+}
+
+type Gateway struct {
+	VPNClientAddressPool      *AddressSpace         `xml:"VPNClientAddressPool,omitempty"`
+	ConnectionsToLocalNetwork []LocalNetworkSiteRef `xml:"ConnectionsToLocalNetwork>LocalNetworkSiteRef,omitempty"`
+	UnknownElements           []UnknownElement      `xml:",any"`
+}
+
+type LocalNetworkSiteRef struct {
+	Name       string `xml:"name,attr"`
+	Connection *Connection
+}
+
+type Connection struct {
+	Type string `xml:"type,attr"`
+}
+
+// UnknownElement keeps an element of the network configuration that the
+// entity structs do not model, so a read-modify-write does not drop it.
+type UnknownElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXml string     `xml:",innerxml"`
+}
@@ -0,0 +1,277 @@
+package vnetClient
+
+import (
+	"encoding/xml"
+	"fmt"
+	azure "github.com/MSOpenTech/azure-sdk-for-go"
+	"net"
+	"strings"
+)
+
+const (
+	azureNetworkConfigurationURL = "services/networking/media"
+	networkConfigurationXmlns    = "http://schemas.microsoft.com/ServiceHosting/2011/07/NetworkConfiguration"
+	networkConfigurationHeader   = "Content-Type"
+	networkConfigurationType     = "text/plain"
+	resourceNotFoundErrorCode    = "ResourceNotFound"
+
+	virtualNetworkExistsError   = "Virtual network %s already exists"
+	virtualNetworkNotFoundError = "Virtual network %s was not found"
+	subnetExistsError           = "Subnet %s already exists in virtual network %s"
+	subnetNotFoundError         = "Subnet %s was not found in virtual network %s"
+	invalidIPAddressError       = "Invalid IP address: %s"
+	ipAddressNotInSubnetsError  = "IP address %s is not in subnets %s of virtual network %s"
+)
+
+func GetVirtualNetworkConfiguration() (*NetworkConfiguration, error) {
+	networkConfiguration := new(NetworkConfiguration)
+	networkConfiguration.Xmlns = networkConfigurationXmlns
+
+	response, err := azure.SendAzureGetRequest(azureNetworkConfigurationURL)
+	if err != nil {
+		// A subscription without virtual networks has no network configuration yet
+		azureErr, ok := err.(*azure.AzureError)
+		if ok && azureErr.Code == resourceNotFoundErrorCode {
+			return networkConfiguration, nil
+		}
+
+		return nil, err
+	}
+
+	err = xml.Unmarshal(response, networkConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	networkConfiguration.Xmlns = networkConfigurationXmlns
+	return networkConfiguration, nil
+}
+
+func SetVirtualNetworkConfiguration(networkConfiguration *NetworkConfiguration) error {
+	if networkConfiguration == nil {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "networkConfiguration")
+	}
+
+	networkConfiguration.Xmlns = networkConfigurationXmlns
+	networkConfigurationBytes, err := xml.Marshal(networkConfiguration)
+	if err != nil {
+		return err
+	}
+
+	requestId, err := azure.SendAzurePutRequestWithHeaders(azureNetworkConfigurationURL, map[string]string{networkConfigurationHeader: networkConfigurationType}, networkConfigurationBytes)
+	if err != nil {
+		return err
+	}
+
+	return azure.WaitAsyncOperation(requestId)
+}
+
+func UpdateVirtualNetworkConfiguration(update func(*NetworkConfiguration) error) error {
+	if update == nil {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "update")
+	}
+
+	networkConfiguration, err := GetVirtualNetworkConfiguration()
+	if err != nil {
+		return err
+	}
+
+	err = update(networkConfiguration)
+	if err != nil {
+		return err
+	}
+
+	return SetVirtualNetworkConfiguration(networkConfiguration)
+}
+
+func GetVirtualNetworkSite(name string) (*VirtualNetworkSite, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	networkConfiguration, err := GetVirtualNetworkConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	index := findVirtualNetworkSite(networkConfiguration, name)
+	if index < 0 {
+		return nil, fmt.Errorf(virtualNetworkNotFoundError, name)
+	}
+
+	return &networkConfiguration.VirtualNetworkConfiguration.VirtualNetworkSites[index], nil
+}
+
+func AddVirtualNetworkSite(virtualNetworkSite VirtualNetworkSite) error {
+	if len(virtualNetworkSite.Name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "virtualNetworkSite.Name")
+	}
+	if len(virtualNetworkSite.Location) == 0 && len(virtualNetworkSite.AffinityGroup) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "virtualNetworkSite.Location")
+	}
+	if len(virtualNetworkSite.AddressSpace.AddressPrefix) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "virtualNetworkSite.AddressSpace")
+	}
+
+	return UpdateVirtualNetworkConfiguration(func(networkConfiguration *NetworkConfiguration) error {
+		if findVirtualNetworkSite(networkConfiguration, virtualNetworkSite.Name) >= 0 {
+			return fmt.Errorf(virtualNetworkExistsError, virtualNetworkSite.Name)
+		}
+
+		virtualNetworkConfiguration := &networkConfiguration.VirtualNetworkConfiguration
+		virtualNetworkConfiguration.VirtualNetworkSites = append(virtualNetworkConfiguration.VirtualNetworkSites, virtualNetworkSite)
+		return nil
+	})
+}
+
+func RemoveVirtualNetworkSite(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "name")
+	}
+
+	return UpdateVirtualNetworkConfiguration(func(networkConfiguration *NetworkConfiguration) error {
+		index := findVirtualNetworkSite(networkConfiguration, name)
+		if index < 0 {
+			return fmt.Errorf(virtualNetworkNotFoundError, name)
+		}
+
+		sites := networkConfiguration.VirtualNetworkConfiguration.VirtualNetworkSites
+		networkConfiguration.VirtualNetworkConfiguration.VirtualNetworkSites = append(sites[:index], sites[index+1:]...)
+		return nil
+	})
+}
+
+func AddSubnet(virtualNetworkName string, subnet Subnet) error {
+	if len(virtualNetworkName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "virtualNetworkName")
+	}
+	if len(subnet.Name) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "subnet.Name")
+	}
+	if len(subnet.AddressPrefix) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "subnet.AddressPrefix")
+	}
+
+	return UpdateVirtualNetworkConfiguration(func(networkConfiguration *NetworkConfiguration) error {
+		index := findVirtualNetworkSite(networkConfiguration, virtualNetworkName)
+		if index < 0 {
+			return fmt.Errorf(virtualNetworkNotFoundError, virtualNetworkName)
+		}
+
+		site := &networkConfiguration.VirtualNetworkConfiguration.VirtualNetworkSites[index]
+		if findSubnet(site, subnet.Name) >= 0 {
+			return fmt.Errorf(subnetExistsError, subnet.Name, virtualNetworkName)
+		}
+
+		site.Subnets = append(site.Subnets, subnet)
+		return nil
+	})
+}
+
+func RemoveSubnet(virtualNetworkName, subnetName string) error {
+	if len(virtualNetworkName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "virtualNetworkName")
+	}
+	if len(subnetName) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "subnetName")
+	}
+
+	return UpdateVirtualNetworkConfiguration(func(networkConfiguration *NetworkConfiguration) error {
+		index := findVirtualNetworkSite(networkConfiguration, virtualNetworkName)
+		if index < 0 {
+			return fmt.Errorf(virtualNetworkNotFoundError, virtualNetworkName)
+		}
+
+		site := &networkConfiguration.VirtualNetworkConfiguration.VirtualNetworkSites[index]
+		subnetIndex := findSubnet(site, subnetName)
+		if subnetIndex < 0 {
+			return fmt.Errorf(subnetNotFoundError, subnetName, virtualNetworkName)
+		}
+
+		site.Subnets = append(site.Subnets[:subnetIndex], site.Subnets[subnetIndex+1:]...)
+		return nil
+	})
+}
+
+func ResolveSubnets(virtualNetworkName string, subnetNames []string) error {
+	site, err := GetVirtualNetworkSite(virtualNetworkName)
+	if err != nil {
+		return err
+	}
+
+	return verifySubnets(site, subnetNames)
+}
+
+// ResolveStaticIPAddress checks that the subnets exist in the virtual network
+// and that ipAddress is inside the address range of one of them.
+func ResolveStaticIPAddress(virtualNetworkName string, subnetNames []string, ipAddress string) error {
+	if len(ipAddress) == 0 {
+		return fmt.Errorf(azure.ParamNotSpecifiedError, "ipAddress")
+	}
+
+	site, err := GetVirtualNetworkSite(virtualNetworkName)
+	if err != nil {
+		return err
+	}
+
+	err = verifySubnets(site, subnetNames)
+	if err != nil {
+		return err
+	}
+
+	return verifyStaticIPAddress(site, subnetNames, ipAddress)
+}
+
+func verifySubnets(site *VirtualNetworkSite, subnetNames []string) error {
+	for _, subnetName := range subnetNames {
+		if findSubnet(site, subnetName) < 0 {
+			return fmt.Errorf(subnetNotFoundError, subnetName, site.Name)
+		}
+	}
+
+	return nil
+}
+
+func verifyStaticIPAddress(site *VirtualNetworkSite, subnetNames []string, ipAddress string) error {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return fmt.Errorf(invalidIPAddressError, ipAddress)
+	}
+
+	for _, subnetName := range subnetNames {
+		index := findSubnet(site, subnetName)
+		if index < 0 {
+			continue
+		}
+
+		_, subnetRange, err := net.ParseCIDR(site.Subnets[index].AddressPrefix)
+		if err != nil {
+			return err
+		}
+		if subnetRange.Contains(ip) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(ipAddressNotInSubnetsError, ipAddress, strings.Join(subnetNames, ", "), site.Name)
+}
+
+func findVirtualNetworkSite(networkConfiguration *NetworkConfiguration, name string) int {
+	for i, site := range networkConfiguration.VirtualNetworkConfiguration.VirtualNetworkSites {
+		if site.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func findSubnet(site *VirtualNetworkSite, name string) int {
+	for i, subnet := range site.Subnets {
+		if subnet.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
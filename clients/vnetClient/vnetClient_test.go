@@ -0,0 +1,111 @@
+package vnetClient
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const testNetworkConfiguration = `<NetworkConfiguration xmlns="http://schemas.microsoft.com/ServiceHosting/2011/07/NetworkConfiguration">
+  <VirtualNetworkConfiguration>
+    <Dns>
+      <DnsServers>
+        <DnsServer name="dns1" IPAddress="10.0.0.4" />
+      </DnsServers>
+    </Dns>
+    <LocalNetworkSites>
+      <LocalNetworkSite name="office">
+        <AddressSpace><AddressPrefix>192.168.0.0/16</AddressPrefix></AddressSpace>
+        <VPNGatewayAddress>1.2.3.4</VPNGatewayAddress>
+      </LocalNetworkSite>
+    </LocalNetworkSites>
+    <VirtualNetworkSites>
+      <VirtualNetworkSite name="vnet1" Location="West US" Reserved="yes">
+        <AddressSpace><AddressPrefix>10.0.0.0/16</AddressPrefix></AddressSpace>
+        <Subnets>
+          <Subnet name="front">
+            <AddressPrefix>10.0.1.0/24</AddressPrefix>
+            <NetworkSecurityGroup name="nsg1" />
+          </Subnet>
+        </Subnets>
+        <FutureElement kind="x"><Child>value</Child></FutureElement>
+      </VirtualNetworkSite>
+    </VirtualNetworkSites>
+  </VirtualNetworkConfiguration>
+</NetworkConfiguration>`
+
+func Test_NetworkConfigurationKeepsUnknownXml(t *testing.T) {
+	networkConfiguration := new(NetworkConfiguration)
+	err := xml.Unmarshal([]byte(testNetworkConfiguration), networkConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	site := &networkConfiguration.VirtualNetworkConfiguration.VirtualNetworkSites[0]
+	site.Subnets = append(site.Subnets, Subnet{Name: "back", AddressPrefix: "10.0.2.0/24"})
+
+	output, err := xml.Marshal(networkConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reparsed := new(NetworkConfiguration)
+	err = xml.Unmarshal(output, reparsed)
+	if err != nil {
+		t.Fatalf("Marshalled configuration is not valid: %s", err)
+	}
+
+	reparsedSite := reparsed.VirtualNetworkConfiguration.VirtualNetworkSites[0]
+	if len(reparsedSite.Subnets) != 2 || reparsedSite.Subnets[1].Name != "back" {
+		t.Errorf("Added subnet is missing: %+v", reparsedSite.Subnets)
+	}
+	if elements := reparsedSite.Subnets[0].UnknownElements; len(elements) != 1 || elements[0].XMLName.Local != "NetworkSecurityGroup" {
+		t.Errorf("Unknown subnet element was dropped: %+v", elements)
+	}
+	if elements := reparsedSite.UnknownElements; len(elements) != 1 || elements[0].XMLName.Local != "FutureElement" || !strings.Contains(elements[0].InnerXml, "<Child>value</Child>") {
+		t.Errorf("Unknown site element was dropped: %+v", elements)
+	}
+	if attrs := reparsedSite.UnknownAttrs; len(attrs) != 1 || attrs[0].Name.Local != "Reserved" || attrs[0].Value != "yes" {
+		t.Errorf("Unknown site attribute was dropped: %+v", attrs)
+	}
+	if len(reparsed.VirtualNetworkConfiguration.UnknownElements) != 0 {
+		t.Errorf("Modelled elements were decoded as unknown: %+v", reparsed.VirtualNetworkConfiguration.UnknownElements)
+	}
+	if servers := reparsed.VirtualNetworkConfiguration.Dns.DnsServers; len(servers) != 1 || servers[0].IPAddress != "10.0.0.4" {
+		t.Errorf("DNS servers were not kept: %+v", servers)
+	}
+}
+
+func Test_verifyStaticIPAddress(t *testing.T) {
+	site := &VirtualNetworkSite{Name: "vnet1", Subnets: []Subnet{
+		{Name: "front", AddressPrefix: "10.0.1.0/24"},
+		{Name: "back", AddressPrefix: "10.0.2.0/24"},
+	}}
+
+	testCases := []struct {
+		subnetNames []string
+		ipAddress   string
+		valid       bool
+	}{
+		{[]string{"front"}, "10.0.1.10", true},
+		{[]string{"front", "back"}, "10.0.2.200", true},
+		{[]string{"front"}, "10.0.2.10", false},
+		{[]string{"front"}, "10.0.1.256", false},
+		{[]string{"front"}, "not-an-ip", false},
+		{[]string{"missing"}, "10.0.1.10", false},
+	}
+
+	for _, testCase := range testCases {
+		err := verifyStaticIPAddress(site, testCase.subnetNames, testCase.ipAddress)
+		if testCase.valid && err != nil {
+			t.Errorf("Unexpected error for %s in %v: %s", testCase.ipAddress, testCase.subnetNames, err)
+		}
+		if !testCase.valid && err == nil {
+			t.Errorf("Expected an error for %s in %v", testCase.ipAddress, testCase.subnetNames)
+		}
+	}
+
+	if err := verifySubnets(site, []string{"front", "missing"}); err == nil {
+		t.Errorf("Expected an error for a missing subnet")
+	}
+}
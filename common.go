@@ -3,12 +3,14 @@ package azureSdkForGo
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"github.com/MSOpenTech/azure-sdk-for-go/core/http"
 	"github.com/MSOpenTech/azure-sdk-for-go/core/tls"
 	"io"
+	"io/ioutil"
 	"os/exec"
 	"strings"
 	"time"
@@ -55,6 +57,10 @@ func SendAzurePostRequest(url string, data []byte) (string, error) {
 	return requestId[0], nil
 }
 
+func SendAzurePutRequest(url string, data []byte) (string, error) {
+	return SendAzurePutRequestWithHeaders(url, nil, data)
+}
+
 func SendAzureDeleteRequest(url string) (string, error) {
 	if len(url) == 0 {
 		return "", fmt.Errorf(ParamNotSpecifiedError, "url")
@@ -79,7 +85,7 @@ func SendAzureRequest(url string, requestType string, data []byte) (*http.Respon
 
 	client := createHttpClient()
 
-	response, err := sendRequest(client, url, requestType, data, 7)
+	response, err := sendRequest(client, url, requestType, createRequestHeaders(nil), data, 7)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +93,60 @@ func SendAzureRequest(url string, requestType string, data []byte) (*http.Respon
 	return response, nil
 }
 
+// SendAzureGetRequestWithHeaders sends a GET request with extra headers, which
+// replace the default ones. It is used by services needing another API version.
+func SendAzureGetRequestWithHeaders(url string, headers map[string]string) ([]byte, error) {
+	if len(url) == 0 {
+		return nil, fmt.Errorf(ParamNotSpecifiedError, "url")
+	}
+
+	client := createHttpClient()
+
+	response, err := sendRequest(client, url, "GET", createRequestHeaders(headers), nil, 7)
+	if err != nil {
+		return nil, err
+	}
+
+	responseContent := getResponseBody(response)
+	return responseContent, nil
+}
+
+// SendAzurePostRequestWithHeaders sends a POST request with extra headers,
+// which replace the default ones.
+func SendAzurePostRequestWithHeaders(url string, headers map[string]string, data []byte) (string, error) {
+	if len(url) == 0 {
+		return "", fmt.Errorf(ParamNotSpecifiedError, "url")
+	}
+
+	client := createHttpClient()
+
+	response, err := sendRequest(client, url, "POST", createRequestHeaders(headers), data, 7)
+	if err != nil {
+		return "", err
+	}
+
+	requestId := response.Header[requestIdHeader]
+	return requestId[0], nil
+}
+
+// SendAzurePutRequestWithHeaders sends a PUT request with extra headers,
+// which replace the default ones.
+func SendAzurePutRequestWithHeaders(url string, headers map[string]string, data []byte) (string, error) {
+	if len(url) == 0 {
+		return "", fmt.Errorf(ParamNotSpecifiedError, "url")
+	}
+
+	client := createHttpClient()
+
+	response, err := sendRequest(client, url, "PUT", createRequestHeaders(headers), data, 7)
+	if err != nil {
+		return "", err
+	}
+
+	requestId := response.Header[requestIdHeader]
+	return requestId[0], nil
+}
+
 func ExecuteCommand(command string, input []byte) ([]byte, error) {
 	if len(command) == 0 {
 		return nil, fmt.Errorf(ParamNotSpecifiedError, "command")
@@ -188,8 +248,8 @@ func NewUUID() (string, error) {
 
 //Region private methods starts
 
-func sendRequest(client *http.Client, url string, requestType string, data []byte, numberOfRetries int) (*http.Response, error) {
-	request, reqErr := createAzureRequest(url, requestType, data)
+func sendRequest(client *http.Client, url string, requestType string, headers map[string]string, data []byte, numberOfRetries int) (*http.Response, error) {
+	request, reqErr := createAzureRequest(url, requestType, headers, data)
 	if reqErr != nil {
 		return nil, reqErr
 	}
@@ -200,35 +260,44 @@ func sendRequest(client *http.Client, url string, requestType string, data []byt
 			return nil, err
 		}
 
-		return sendRequest(client, url, requestType, data, numberOfRetries-1)
+		return sendRequest(client, url, requestType, headers, data, numberOfRetries-1)
 	}
 
 	if response.StatusCode > 299 {
 		responseContent := getResponseBody(response)
-		azureErr := getAzureError(responseContent)
+		azureErr := getAzureError(response.Status, response.Header.Get(contentHeader), responseContent)
 		if azureErr != nil {
 			if numberOfRetries == 0 {
 				return nil, azureErr
 			}
 
-			return sendRequest(client, url, requestType, data, numberOfRetries-1)
+			return sendRequest(client, url, requestType, headers, data, numberOfRetries-1)
 		}
 	}
 
 	return response, nil
 }
 
-func getAzureError(responseBody []byte) error {
-	error := new(AzureError)
-	err := xml.Unmarshal(responseBody, error)
-	if err != nil {
-		return err
+// getAzureError decodes the error body of a failed request. The management
+// API answers in XML while some services, such as monitoring, answer in JSON.
+// A body that can not be decoded is returned as the message of the error.
+func getAzureError(status, contentType string, responseBody []byte) error {
+	azureError := new(AzureError)
+
+	var err error
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		err = json.Unmarshal(responseBody, azureError)
+	} else {
+		err = xml.Unmarshal(responseBody, azureError)
+	}
+	if err != nil || len(azureError.Code) == 0 && len(azureError.Message) == 0 {
+		return &AzureError{Code: status, Message: strings.TrimSpace(string(responseBody))}
 	}
 
-	return error
+	return azureError
 }
 
-func createAzureRequest(url string, requestType string, data []byte) (*http.Request, error) {
+func createAzureRequest(url string, requestType string, headers map[string]string, data []byte) (*http.Request, error) {
 	var request *http.Request
 	var err error
 
@@ -244,12 +313,26 @@ func createAzureRequest(url string, requestType string, data []byte) (*http.Requ
 		return nil, err
 	}
 
-	request.Header.Add(msVersionHeader, msVersionHeaderValue)
-	request.Header.Add(contentHeader, contentHeaderValue)
+	for name, value := range headers {
+		request.Header.Add(name, value)
+	}
 
 	return request, nil
 }
 
+func createRequestHeaders(extraHeaders map[string]string) map[string]string {
+	headers := map[string]string{
+		msVersionHeader: msVersionHeaderValue,
+		contentHeader:   contentHeaderValue,
+	}
+
+	for name, value := range extraHeaders {
+		headers[name] = value
+	}
+
+	return headers
+}
+
 func createHttpClient() *http.Client {
 	cert, _ := tls.X509KeyPair(GetPublishSettings().SubscriptionCert, GetPublishSettings().SubscriptionKey)
 
@@ -266,6 +349,10 @@ func createHttpClient() *http.Client {
 }
 
 func getResponseBody(response *http.Response) []byte {
+	if response.ContentLength < 0 {
+		responseBody, _ := ioutil.ReadAll(response.Body)
+		return responseBody
+	}
 
 	responseBody := make([]byte, response.ContentLength)
 	io.ReadFull(response.Body, responseBody)
@@ -275,7 +362,7 @@ func getResponseBody(response *http.Response) []byte {
 //Region private methods ends
 
 type AzureError struct {
-	XMLName xml.Name `xml:"Error"`
+	XMLName xml.Name `xml:"Error" json:"-"`
 	Code    string
 	Message string
 }
@@ -0,0 +1,38 @@
+package azureSdkForGo
+
+import (
+	"testing"
+)
+
+func Test_getAzureError(t *testing.T) {
+	testCases := []struct {
+		contentType     string
+		body            string
+		expectedCode    string
+		expectedMessage string
+	}{
+		{"application/xml; charset=utf-8", `<Error xmlns="http://schemas.microsoft.com/windowsazure"><Code>ResourceNotFound</Code><Message>No deployments were found.</Message></Error>`, "ResourceNotFound", "No deployments were found."},
+		{"application/json; charset=utf-8", `{"Code":"InvalidParameter","Message":"The time grain is invalid."}`, "InvalidParameter", "The time grain is invalid."},
+		{"application/json", `{"code":"Forbidden","message":"Access denied."}`, "Forbidden", "Access denied."},
+		{"application/json", ``, "400 Bad Request", ""},
+		{"application/xml", ``, "400 Bad Request", ""},
+		{"text/html", `<html><body>Bad Request</body></html>`, "400 Bad Request", "<html><body>Bad Request</body></html>"},
+		{"", `Service unavailable`, "400 Bad Request", "Service unavailable"},
+	}
+
+	for _, testCase := range testCases {
+		err := getAzureError("400 Bad Request", testCase.contentType, []byte(testCase.body))
+		azureErr, ok := err.(*AzureError)
+		if !ok {
+			t.Errorf("Expected: *AzureError for %s, got: %T %v", testCase.body, err, err)
+			continue
+		}
+
+		if azureErr.Code != testCase.expectedCode {
+			t.Errorf("Expected: %s, got: %s", testCase.expectedCode, azureErr.Code)
+		}
+		if azureErr.Message != testCase.expectedMessage {
+			t.Errorf("Expected: %s, got: %s", testCase.expectedMessage, azureErr.Message)
+		}
+	}
+}
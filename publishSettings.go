@@ -8,7 +8,7 @@ import (
 	"io/ioutil"
 )
 
-var settings publishSettings = publishSettings{}
+var settings publishSettings = publishSettings{catalogCache: newCatalogCache(DefaultCatalogCacheTTL)}
 
 func GetPublishSettings() publishSettings {
 	return settings
@@ -18,6 +18,8 @@ func setPublishSettings(id string, cert []byte, key []byte) {
 	settings.SubscriptionID = id
 	settings.SubscriptionCert = cert
 	settings.SubscriptionKey = key
+	// Catalogs differ between subscriptions
+	settings.catalogCache.clear()
 }
 
 func ImportPublishSettings(id string, certPath string) error {
@@ -113,6 +115,7 @@ type publishSettings struct {
 	SubscriptionID   string
 	SubscriptionCert []byte
 	SubscriptionKey  []byte
+	catalogCache     *catalogCache
 }
 
 type publishData struct {